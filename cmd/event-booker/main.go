@@ -2,18 +2,24 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/K1la/event-booker/internal/api/handler"
 	"github.com/K1la/event-booker/internal/api/router"
 	"github.com/K1la/event-booker/internal/api/server"
 	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/payment"
 	"github.com/K1la/event-booker/internal/rabbitmq"
 	"github.com/K1la/event-booker/internal/repository"
 	"github.com/K1la/event-booker/internal/sender"
 	"github.com/K1la/event-booker/internal/service"
 	"github.com/wb-go/wbf/zlog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	// venue timezones must load in the alpine image, which has no zoneinfo
+	_ "time/tzdata"
 )
 
 func main() {
@@ -26,10 +32,14 @@ func main() {
 	zlog.Logger.Info().Interface("cfg rabbitmq", cfg.RabbitMQ).Msg("cfg rabbitmq in main")
 	rabmq := rabbitmq.New(cfg)
 	snder := sender.New()
-	srvc := service.New(repo, rabmq, snder)
+	provider, checkout, err := newPaymentProvider(cfg)
+	if err != nil {
+		zlog.Logger.Fatal().Err(err).Msg("failed to set up payment provider")
+	}
+	srvc := service.New(repo, rabmq, snder, provider, cfg)
 
 	hndlr := handler.New(srvc)
-	r := router.New(hndlr)
+	r := router.New(hndlr, checkout)
 	s := server.New(cfg.HTTPServer.Address, r)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -39,8 +49,19 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// TODO: use queue in service
+	srvc.StartOutboxRelay(ctx, time.Duration(cfg.Outbox.RelayInterval)*time.Second, cfg.Outbox.BatchSize)
 	srvc.StartWorker(ctx)
+	srvc.StartIdempotencyKeyCleanup(ctx,
+		time.Duration(cfg.Idempotency.TTL)*time.Second, time.Duration(cfg.Idempotency.CleanupInterval)*time.Second)
+	srvc.StartNotifier(ctx,
+		time.Duration(cfg.Notifier.Interval)*time.Second, cfg.Notifier.BatchSize, cfg.Notifier.MaxAttempts)
+	srvc.StartEventCompleter(ctx, time.Duration(cfg.Completer.Interval)*time.Second)
+	srvc.StartHoldSweeper(ctx, time.Duration(cfg.Holds.SweepInterval)*time.Second, cfg.Holds.BatchSize)
+	srvc.StartRefunder(ctx,
+		time.Duration(cfg.Refunds.Interval)*time.Second, cfg.Refunds.BatchSize, cfg.Refunds.MaxAttempts)
+	if cfg.Sweeper.Enabled {
+		srvc.StartExpirySweeper(ctx, time.Duration(cfg.Sweeper.Interval)*time.Second, cfg.Sweeper.BatchSize)
+	}
 
 	go func() {
 		sig := <-sigChan
@@ -53,3 +74,23 @@ func main() {
 	}
 	zlog.Logger.Info().Msg("successfully started server on " + cfg.HTTPServer.Address)
 }
+
+// newPaymentProvider picks the provider named in payment.provider together with the handler of its
+// own checkout pages, if it serves any. Without a provider paid bookings can not be paid.
+// The fake provider confirms payments nobody made, so it only starts in the dev environment.
+func newPaymentProvider(cfg *config.Config) (service.PaymentProvider, http.Handler, error) {
+	switch cfg.Payment.Provider {
+	case "":
+		zlog.Logger.Warn().Msg("no payment provider configured, paid bookings can not be paid")
+		return nil, nil, nil
+	case payment.FakeProviderName:
+		if cfg.Env != config.EnvDev {
+			return nil, nil, fmt.Errorf("payment provider %q is only allowed with env %q, got %q",
+				payment.FakeProviderName, config.EnvDev, cfg.Env)
+		}
+		fake := payment.NewFake(cfg)
+		return fake, fake, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown payment provider %q", cfg.Payment.Provider)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// events/:id
+func (h *Handler) DeleteEvent(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	if err = h.service.DeleteEvent(c.Request.Context(), eventID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEventNotFound):
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrEventHasPendingRefunds),
+			errors.Is(err, repository.ErrEventHasBookings):
+			zlog.Logger.Error().Err(err).Msg("event can not be deleted")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("DeleteEvent failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Msg("DeleteEvent success")
+	response.OK(c, ginext.H{"status": "event deleted"})
+}
@@ -54,6 +54,149 @@ func (h *Handler) GetEvents(c *ginext.Context) {
 	response.OK(c, events)
 }
 
+// events/:id/ticket-types
+func (h *Handler) GetTicketTypes(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	ticketTypes, err := h.service.GetTicketTypes(c.Request.Context(), eventID)
+	if err != nil {
+		if errors.Is(err, repository.ErrEventNotFound) {
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get ticket types")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Msg("successfully handled GET ticket types")
+	response.OK(c, ticketTypes)
+}
+
+// bookings/:id/history
+func (h *Handler) GetBookingHistory(c *ginext.Context) {
+	bookingID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid booking id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	history, err := h.service.GetBookingHistory(c.Request.Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchBooking) {
+			zlog.Logger.Error().Err(err).Msg("booking not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get booking history")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("bookingID", bookingID).Msg("successfully handled GET booking history")
+	response.OK(c, history)
+}
+
+// bookings/:id/refunds
+func (h *Handler) GetBookingRefunds(c *ginext.Context) {
+	bookingID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid booking id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	refunds, err := h.service.GetBookingRefunds(c.Request.Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchBooking) {
+			zlog.Logger.Error().Err(err).Msg("booking not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get booking refunds")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("bookingID", bookingID).Msg("successfully handled GET booking refunds")
+	response.OK(c, refunds)
+}
+
+// promo-codes
+func (h *Handler) GetPromoCodes(c *ginext.Context) {
+	promoCodes, err := h.service.GetPromoCodes(c.Request.Context())
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("could not get promo codes")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET promo codes")
+	response.OK(c, promoCodes)
+}
+
+// promo-codes/:id/report
+func (h *Handler) GetPromoCodeReport(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid promo code id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	report, err := h.service.GetPromoCodeReport(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchPromoCode) {
+			zlog.Logger.Error().Err(err).Msg("promo code not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get promo code report")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("promoCodeID", id).Msg("successfully handled GET promo code report")
+	response.OK(c, report)
+}
+
+// events/:id/notifications
+func (h *Handler) GetEventNotifications(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	report, err := h.service.GetEventNotifications(c.Request.Context(), eventID)
+	if err != nil {
+		if errors.Is(err, repository.ErrEventNotFound) {
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get event notifications")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Msg("successfully handled GET event notifications")
+	response.OK(c, report)
+}
+
 func parseUUIDParam(c *ginext.Context, param string) (uuid.UUID, error) {
 	idStr := c.Param(param)
 	id, err := uuid.Parse(idStr)
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// events/:id/holds
+func (h *Handler) CreateHold(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var hold dto.CreateHold
+	if err = c.ShouldBindJSON(&hold); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+	hold.EventID = eventID
+
+	held, err := h.service.CreateHold(c.Request.Context(), &hold)
+	if err != nil {
+		if failBookingLimit(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, repository.ErrInvalidPlacesCount),
+			errors.Is(err, repository.ErrTicketTypeRequired),
+			errors.Is(err, repository.ErrSeatCountMismatch),
+			errors.Is(err, repository.ErrSeatSelectionRequired),
+			errors.Is(err, repository.ErrNoSuchSeat):
+			zlog.Logger.Error().Err(err).Msg("invalid seat hold")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrNoSuchEvent),
+			errors.Is(err, repository.ErrEventNotFound),
+			errors.Is(err, repository.ErrNoSuchTicketType):
+			zlog.Logger.Error().Err(err).Msg("event or ticket type not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrSalesNotOpen),
+			errors.Is(err, repository.ErrSalesClosed):
+			zlog.Logger.Error().Err(err).Msg("outside of the sales window")
+			response.Fail(c, http.StatusForbidden, err)
+		case errors.Is(err, repository.ErrNoSeatsAvailable),
+			errors.Is(err, repository.ErrSeatTaken),
+			errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted),
+			errors.Is(err, repository.ErrEventNotOnSale):
+			zlog.Logger.Error().Err(err).Msg("seats can not be held")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CreateHold failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("hold", held).Msg("CreateHold success")
+	response.Created(c, held)
+}
+
+// holds/:id
+func (h *Handler) GetHold(c *ginext.Context) {
+	holdID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid hold id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	hold, err := h.service.GetHold(c.Request.Context(), holdID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchHold) {
+			zlog.Logger.Error().Err(err).Msg("seat hold not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get seat hold")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("holdID", holdID).Msg("successfully handled GET seat hold")
+	response.OK(c, hold)
+}
+
+// holds/:id/book
+func (h *Handler) ConvertHold(c *ginext.Context) {
+	holdID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid hold id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var req dto.ConvertHold
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			zlog.Logger.Error().Err(err).Msg("bind json failed")
+			response.BadRequest(c, err)
+			return
+		}
+	}
+
+	booking, err := h.service.ConvertHold(c.Request.Context(), holdID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchHold),
+			errors.Is(err, repository.ErrNoSuchPromoCode):
+			zlog.Logger.Error().Err(err).Msg("seat hold or promo code not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrSalesNotOpen),
+			errors.Is(err, repository.ErrSalesClosed):
+			zlog.Logger.Error().Err(err).Msg("outside of the sales window")
+			response.Fail(c, http.StatusForbidden, err)
+		case errors.Is(err, repository.ErrHoldNotActive),
+			errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted),
+			errors.Is(err, repository.ErrEventNotOnSale):
+			zlog.Logger.Error().Err(err).Msg("seat hold can not be booked")
+			response.Fail(c, http.StatusConflict, err)
+		case errors.Is(err, repository.ErrHoldExpired):
+			zlog.Logger.Error().Err(err).Msg("seat hold has expired")
+			response.Fail(c, http.StatusGone, err)
+		case errors.Is(err, repository.ErrPromoCodeExpired),
+			errors.Is(err, repository.ErrPromoCodeExhausted),
+			errors.Is(err, repository.ErrPromoCodeNotApplicable):
+			zlog.Logger.Error().Err(err).Msg("promo code can not be used")
+			response.Fail(c, http.StatusUnprocessableEntity, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("ConvertHold failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("booking", booking).Msg("ConvertHold success")
+	response.OK(c, booking)
+}
+
+// holds/:id
+func (h *Handler) ReleaseHold(c *ginext.Context) {
+	holdID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid hold id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	hold, err := h.service.ReleaseHold(c.Request.Context(), holdID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchHold):
+			zlog.Logger.Error().Err(err).Msg("seat hold not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrHoldNotActive),
+			errors.Is(err, repository.ErrHoldExpired):
+			zlog.Logger.Error().Err(err).Msg("seat hold is not active")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("ReleaseHold failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("hold", hold).Msg("ReleaseHold success")
+	response.OK(c, hold)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotentResponseContent = "application/json; charset=utf-8"
+)
+
+// responseRecorder keeps a copy of everything the handler writes, so the response can be stored.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotent makes the wrapped handler safe to retry: a request carrying an Idempotency-Key
+// is processed once, and repeating it returns the stored status code and body.
+// Reusing a key with a different request is rejected. Requests without the header pass through.
+// Keys are scoped per user, so two users picking the same key do not collide, and a retry
+// of the same user finds its key even when it comes from another address.
+func (h *Handler) Idempotent(route string) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msg("failed to read request body")
+			response.BadRequest(c, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope, err := callerScope(route, body)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Str("key", key).Msg("idempotent request without a user")
+			response.FailCode(c, http.StatusBadRequest, codeTelegramIDRequired, err)
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		record, err := h.service.BeginIdempotentRequest(ctx, scope, key, fingerprint(c, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrIdempotencyKeyReused):
+				zlog.Logger.Error().Err(err).Str("key", key).Msg("idempotency key reused")
+				response.Fail(c, http.StatusUnprocessableEntity, err)
+			case errors.Is(err, repository.ErrIdempotencyKeyInProgress):
+				zlog.Logger.Error().Err(err).Str("key", key).Msg("idempotent request in progress")
+				response.Fail(c, http.StatusConflict, err)
+			default:
+				zlog.Logger.Error().Err(err).Str("key", key).Msg("failed to check idempotency key")
+				response.Internal(c, err)
+			}
+			c.Abort()
+			return
+		}
+
+		if record != nil {
+			zlog.Logger.Info().Str("key", key).Msg("replaying idempotent response")
+			c.Header(idempotentReplayedHeader, "true")
+			c.Data(record.StatusCode, idempotentResponseContent, record.Response)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// a panicking handler must not leave the key in progress until it expires
+		defer func() {
+			if p := recover(); p != nil {
+				err := h.service.CompleteIdempotentRequest(context.WithoutCancel(ctx), scope, key,
+					http.StatusInternalServerError, nil)
+				if err != nil {
+					zlog.Logger.Error().Err(err).Str("key", key).Msg("failed to release idempotency key")
+				}
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		// the client may be gone already, the response must be stored anyway
+		err = h.service.CompleteIdempotentRequest(context.WithoutCancel(ctx), scope, key,
+			recorder.Status(), recorder.body.Bytes())
+		if err != nil {
+			zlog.Logger.Error().Err(err).Str("key", key).Msg("failed to store idempotent response")
+		}
+	}
+}
+
+// callerScope binds the keys of a route to the user who sent them. The API has no accounts,
+// so the user is the telegram_id of the request; the client address is not used, because
+// it changes between retries of a mobile client and can be forged through X-Forwarded-For.
+func callerScope(route string, body []byte) (string, error) {
+	var caller struct {
+		TelegramID int `json:"telegram_id"`
+	}
+	if err := json.Unmarshal(body, &caller); err != nil || caller.TelegramID <= 0 {
+		return "", fmt.Errorf("%w with an %s", repository.ErrTelegramIDRequired, IdempotencyKeyHeader)
+	}
+	return route + ":" + strconv.Itoa(caller.TelegramID), nil
+}
+
+// fingerprint identifies the request a key was first used with: the route and the exact body.
+func fingerprint(c *ginext.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -9,10 +9,49 @@ import (
 
 type ServiceI interface {
 	CreateEvent(ctx context.Context, event *dto.CreateEvent) (*model.Event, error)
+	UpdateEvent(ctx context.Context, eventID uuid.UUID, upd *dto.UpdateEvent) (*model.Event, error)
+	DeleteEvent(ctx context.Context, eventID uuid.UUID) error
+	CancelEvent(ctx context.Context, eventID uuid.UUID, req *dto.CancelEvent) (*model.EventCancellation, error)
+	RescheduleEvent(ctx context.Context, eventID uuid.UUID, req *dto.RescheduleEvent) (*model.RescheduleResult, error)
+	SetEventStatus(ctx context.Context, eventID uuid.UUID, req *dto.SetEventStatus) (*model.Event, error)
+	CreateEventSeries(ctx context.Context, req *dto.CreateEventSeries) (*model.EventSeries, error)
+	GetEventSeries(ctx context.Context, id uuid.UUID) (*model.EventSeries, error)
+	UpdateEventSeries(ctx context.Context, id uuid.UUID, upd *dto.UpdateEventSeries) (*model.SeriesUpdate, error)
+	CreateCapacityPool(ctx context.Context, req *dto.CreateCapacityPool) (*model.CapacityPool, error)
+	GetCapacityPools(ctx context.Context) ([]*model.CapacityPool, error)
+	GetCapacityPoolByID(ctx context.Context, id uuid.UUID) (*model.CapacityPool, error)
+	UpdateCapacityPool(ctx context.Context, id uuid.UUID, upd *dto.UpdateCapacityPool) (*model.CapacityPool, error)
+	CreateSeatMap(ctx context.Context, req *dto.CreateSeatMap) (*model.SeatMap, error)
+	GetSeatMap(ctx context.Context, id uuid.UUID) (*model.SeatMap, error)
+	GetEventSeats(ctx context.Context, eventID uuid.UUID) ([]model.EventSeat, error)
+	CreateVenue(ctx context.Context, req *dto.CreateVenue) (*model.Venue, error)
+	GetVenues(ctx context.Context) ([]*model.Venue, error)
+	GetVenueByID(ctx context.Context, id uuid.UUID) (*model.Venue, error)
+	UpdateVenue(ctx context.Context, id uuid.UUID, upd *dto.UpdateVenue) (*model.Venue, error)
+	DeleteVenue(ctx context.Context, id uuid.UUID) error
+	CreateTicketType(ctx context.Context, eventID uuid.UUID, req *dto.CreateTicketType) (*model.TicketType, error)
+	GetTicketTypes(ctx context.Context, eventID uuid.UUID) ([]model.TicketType, error)
 	CreateBooking(ctx context.Context, booking *dto.CreateBooking) (*model.Booking, error)
-	ConfirmBookingPayment(ctx context.Context, eventID uuid.UUID) error
-	CancelBooking(ctx context.Context, bookingID *dto.QueueMessage) error
+	ConfirmBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error)
+	CancelBooking(ctx context.Context, bookingID uuid.UUID, actor string, req *dto.CancelBooking) (*model.Booking, error)
+	OptOutBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error)
+	PayBooking(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error)
+	HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) error
+	GetBookingRefunds(ctx context.Context, bookingID uuid.UUID) ([]*model.Refund, error)
+	CreateHold(ctx context.Context, hold *dto.CreateHold) (*model.SeatHold, error)
+	GetHold(ctx context.Context, holdID uuid.UUID) (*model.SeatHold, error)
+	ConvertHold(ctx context.Context, holdID uuid.UUID, req *dto.ConvertHold) (*model.Booking, error)
+	ReleaseHold(ctx context.Context, holdID uuid.UUID) (*model.SeatHold, error)
+	CreatePromoCode(ctx context.Context, req *dto.CreatePromoCode) (*model.PromoCode, error)
+	GetPromoCodes(ctx context.Context) ([]*model.PromoCode, error)
+	GetPromoCodeReport(ctx context.Context, id uuid.UUID) (*model.PromoCodeReport, error)
+	JoinWaitlist(ctx context.Context, entry *dto.JoinWaitlist) (*model.WaitlistEntry, error)
+
+	BeginIdempotentRequest(ctx context.Context, scope, key, fingerprint string) (*model.IdempotencyRecord, error)
+	CompleteIdempotentRequest(ctx context.Context, scope, key string, statusCode int, response []byte) error
 
 	GetEventByID(ctx context.Context, eventID uuid.UUID) (*model.Event, error)
 	GetEvents(ctx context.Context) ([]*model.Event, error)
+	GetBookingHistory(ctx context.Context, bookingID uuid.UUID) ([]*model.BookingHistoryEntry, error)
+	GetEventNotifications(ctx context.Context, eventID uuid.UUID) (*model.NotificationReport, error)
 }
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// events/:id
+func (h *Handler) UpdateEvent(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var upd dto.UpdateEvent
+	if err = c.ShouldBindJSON(&upd); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	event, err := h.service.UpdateEvent(c.Request.Context(), eventID, &upd)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEventNotFound),
+			errors.Is(err, repository.ErrNoSuchVenue):
+			zlog.Logger.Error().Err(err).Msg("event or venue not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidPaymentWindow),
+			errors.Is(err, repository.ErrInvalidSalesWindow):
+			zlog.Logger.Error().Err(err).Msg("invalid event")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrEventVersionConflict),
+			errors.Is(err, repository.ErrCapacityBelowBooked),
+			errors.Is(err, repository.ErrCapacityBelowTicketTypes),
+			errors.Is(err, repository.ErrSeatMapTooLarge),
+			errors.Is(err, repository.ErrSeatMapInUse),
+			errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted),
+			errors.Is(err, repository.ErrRescheduleRequired):
+			zlog.Logger.Error().Err(err).Msg("event can not be updated")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("UpdateEvent failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("event", event).Msg("UpdateEvent success")
+	response.OK(c, event)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/payment"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"io"
+	"net/http"
+)
+
+// maxWebhookBodySize limits how much of a webhook body is read before the signature is checked.
+const maxWebhookBodySize = 64 << 10
+
+// bookings/:id/pay
+func (h *Handler) PayBooking(c *ginext.Context) {
+	bookingID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid booking id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("bookingID", bookingID).Msg("PayBooking")
+	paid, err := h.service.PayBooking(c.Request.Context(), bookingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchBooking):
+			zlog.Logger.Error().Err(err).Msg("booking not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidStatusTransition),
+			errors.Is(err, repository.ErrNothingToPay):
+			zlog.Logger.Error().Err(err).Msg("booking can not be paid")
+			response.Fail(c, http.StatusConflict, err)
+		case errors.Is(err, repository.ErrPaymentsDisabled):
+			zlog.Logger.Error().Err(err).Msg("payments are off")
+			response.Fail(c, http.StatusServiceUnavailable, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("PayBooking failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("payment", paid).Msg("PayBooking success")
+	response.OK(c, paid)
+}
+
+// payments/webhook
+func (h *Handler) PaymentWebhook(c *ginext.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to read webhook body")
+		response.BadRequest(c, err)
+		return
+	}
+
+	err = h.service.HandlePaymentWebhook(c.Request.Context(), payload, c.GetHeader(payment.SignatureHeader))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPaymentsDisabled):
+			zlog.Logger.Error().Err(err).Msg("payments are off")
+			response.Fail(c, http.StatusServiceUnavailable, err)
+		case errors.Is(err, payment.ErrInvalidSignature):
+			zlog.Logger.Error().Err(err).Msg("rejected webhook")
+			response.Fail(c, http.StatusUnauthorized, err)
+		case errors.Is(err, repository.ErrNoSuchPayment):
+			zlog.Logger.Error().Err(err).Msg("payment not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrUnknownPaymentStatus):
+			zlog.Logger.Error().Err(err).Msg("unknown payment status")
+			response.BadRequest(c, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("PaymentWebhook failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Msg("PaymentWebhook success")
+	response.OK(c, ginext.H{"status": "processed"})
+}
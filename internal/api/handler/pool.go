@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// capacity-pools
+func (h *Handler) CreateCapacityPool(c *ginext.Context) {
+	var req dto.CreateCapacityPool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	pool, err := h.service.CreateCapacityPool(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidPool) {
+			zlog.Logger.Error().Err(err).Msg("invalid capacity pool")
+			response.BadRequest(c, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("CreateCapacityPool failed")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("pool", pool).Msg("CreateCapacityPool success")
+	response.Created(c, pool)
+}
+
+// capacity-pools
+func (h *Handler) GetCapacityPools(c *ginext.Context) {
+	pools, err := h.service.GetCapacityPools(c.Request.Context())
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("could not get capacity pools")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Int("count", len(pools)).Msg("successfully handled GET capacity pools")
+	response.OK(c, pools)
+}
+
+// capacity-pools/:id
+func (h *Handler) GetCapacityPoolByID(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid capacity pool id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	pool, err := h.service.GetCapacityPoolByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchPool) {
+			zlog.Logger.Error().Err(err).Msg("capacity pool not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get capacity pool")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("poolID", id).Msg("successfully handled GET capacity pool")
+	response.OK(c, pool)
+}
+
+// capacity-pools/:id
+func (h *Handler) UpdateCapacityPool(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid capacity pool id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var upd dto.UpdateCapacityPool
+	if err = c.ShouldBindJSON(&upd); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	pool, err := h.service.UpdateCapacityPool(c.Request.Context(), id, &upd)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchPool):
+			zlog.Logger.Error().Err(err).Msg("capacity pool not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidPool):
+			zlog.Logger.Error().Err(err).Msg("invalid capacity pool")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrPoolCapacityBelowUsed):
+			zlog.Logger.Error().Err(err).Msg("capacity pool can not be shrunk")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("UpdateCapacityPool failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("pool", pool).Msg("UpdateCapacityPool success")
+	response.OK(c, pool)
+}
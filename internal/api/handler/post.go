@@ -24,6 +24,26 @@ func (h *Handler) CreateEvent(c *ginext.Context) {
 
 	event, err := h.service.CreateEvent(c.Request.Context(), &createEvent)
 	if err != nil {
+		if errors.Is(err, repository.ErrEmptyEventTitle) ||
+			errors.Is(err, repository.ErrInvalidTotalSeats) ||
+			errors.Is(err, repository.ErrInvalidPaymentWindow) ||
+			errors.Is(err, repository.ErrInvalidEventStatus) ||
+			errors.Is(err, repository.ErrInvalidSalesWindow) {
+			zlog.Logger.Error().Err(err).Msg("invalid event")
+			response.BadRequest(c, err)
+			return
+		}
+		if errors.Is(err, repository.ErrNoSuchVenue) || errors.Is(err, repository.ErrNoSuchPool) {
+			zlog.Logger.Error().Err(err).Msg("venue or capacity pool not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+		if errors.Is(err, repository.ErrSeatMapTooLarge) {
+			zlog.Logger.Error().Err(err).Msg("event is smaller than the seat map of its venue")
+			response.Fail(c, http.StatusConflict, err)
+			return
+		}
+
 		zlog.Logger.Error().Err(err).Msg("CreateEvent failed")
 		response.Internal(c, err)
 		return
@@ -33,6 +53,109 @@ func (h *Handler) CreateEvent(c *ginext.Context) {
 	response.OK(c, event)
 }
 
+// events/:id/ticket-types
+func (h *Handler) CreateTicketType(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var req dto.CreateTicketType
+	if err = c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	ticketType, err := h.service.CreateTicketType(c.Request.Context(), eventID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidTicketType):
+			zlog.Logger.Error().Err(err).Msg("invalid ticket type")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrEventNotFound),
+			errors.Is(err, repository.ErrNoSuchPool):
+			zlog.Logger.Error().Err(err).Msg("event or capacity pool not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrTicketTypeCapacityExceeded),
+			errors.Is(err, repository.ErrTicketTypeExists),
+			errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted):
+			zlog.Logger.Error().Err(err).Msg("ticket type can not be added")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CreateTicketType failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("ticketType", ticketType).Msg("CreateTicketType success")
+	response.Created(c, ticketType)
+}
+
+// promo-codes
+func (h *Handler) CreatePromoCode(c *ginext.Context) {
+	var req dto.CreatePromoCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	promoCode, err := h.service.CreatePromoCode(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidPromoCode):
+			zlog.Logger.Error().Err(err).Msg("invalid promo code")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrEventNotFound),
+			errors.Is(err, repository.ErrNoSuchTicketType):
+			zlog.Logger.Error().Err(err).Msg("restricted event or ticket type not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrPromoCodeExists):
+			zlog.Logger.Error().Err(err).Msg("promo code already exists")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CreatePromoCode failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("promoCode", promoCode).Msg("CreatePromoCode success")
+	response.Created(c, promoCode)
+}
+
+// Codes of the booking limit errors, so clients can tell which limit was hit.
+const (
+	codeSeatsPerBookingExceeded = "seats_per_booking_exceeded"
+	codeSeatsPerUserExceeded    = "seats_per_user_exceeded"
+	codePendingBookingsExceeded = "pending_bookings_exceeded"
+	codeTelegramIDRequired      = "telegram_id_required"
+)
+
+// failBookingLimit responds to a violated booking limit and reports whether err was one.
+func failBookingLimit(c *ginext.Context, err error) bool {
+	switch {
+	case errors.Is(err, repository.ErrSeatsPerBookingExceeded):
+		response.FailCode(c, http.StatusBadRequest, codeSeatsPerBookingExceeded, err)
+	case errors.Is(err, repository.ErrTelegramIDRequired):
+		response.FailCode(c, http.StatusBadRequest, codeTelegramIDRequired, err)
+	case errors.Is(err, repository.ErrSeatsPerUserExceeded):
+		response.FailCode(c, http.StatusConflict, codeSeatsPerUserExceeded, err)
+	case errors.Is(err, repository.ErrPendingBookingsExceeded):
+		response.FailCode(c, http.StatusConflict, codePendingBookingsExceeded, err)
+	default:
+		return false
+	}
+
+	zlog.Logger.Error().Err(err).Msg("booking limit exceeded")
+	return true
+}
+
 // events/:id/book
 func (h *Handler) CreateBooking(c *ginext.Context) {
 	eventID, err := parseUUIDParam(c, "id")
@@ -52,8 +175,46 @@ func (h *Handler) CreateBooking(c *ginext.Context) {
 
 	booked, err := h.service.CreateBooking(c.Request.Context(), &booking)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("CreateBooking failed")
-		response.Internal(c, err)
+		if failBookingLimit(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, repository.ErrInvalidPlacesCount),
+			errors.Is(err, repository.ErrTicketTypeRequired),
+			errors.Is(err, repository.ErrSeatCountMismatch),
+			errors.Is(err, repository.ErrSeatSelectionRequired),
+			errors.Is(err, repository.ErrNoSuchSeat):
+			zlog.Logger.Error().Err(err).Msg("invalid booking")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrNoSuchEvent),
+			errors.Is(err, repository.ErrEventNotFound),
+			errors.Is(err, repository.ErrNoSuchTicketType),
+			errors.Is(err, repository.ErrNoSuchPromoCode):
+			zlog.Logger.Error().Err(err).Msg("event, ticket type or promo code not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrPromoCodeExpired),
+			errors.Is(err, repository.ErrPromoCodeExhausted),
+			errors.Is(err, repository.ErrPromoCodeNotApplicable):
+			zlog.Logger.Error().Err(err).Msg("promo code can not be used")
+			response.Fail(c, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, repository.ErrSalesNotOpen),
+			errors.Is(err, repository.ErrSalesClosed):
+			zlog.Logger.Error().Err(err).Msg("outside of the sales window")
+			response.Fail(c, http.StatusForbidden, err)
+		case errors.Is(err, repository.ErrNoSeatsAvailable),
+			errors.Is(err, repository.ErrSeatTaken):
+			zlog.Logger.Error().Err(err).Msg("no seats available")
+			response.Fail(c, http.StatusConflict, err)
+		case errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted),
+			errors.Is(err, repository.ErrEventNotOnSale):
+			zlog.Logger.Error().Err(err).Msg("event is not on sale")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CreateBooking failed")
+			response.Internal(c, err)
+		}
 		return
 	}
 
@@ -62,28 +223,310 @@ func (h *Handler) CreateBooking(c *ginext.Context) {
 
 }
 
-// events/:id/confirm
-func (h *Handler) ConfirmBookingPayment(c *ginext.Context) {
+// events/:id/waitlist
+func (h *Handler) JoinWaitlist(c *ginext.Context) {
 	eventID, err := parseUUIDParam(c, "id")
 	if err != nil {
 		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
 		response.BadRequest(c, err)
 		return
 	}
-	zlog.Logger.Info().Interface("eventID", eventID).Msg("ConfirmBookingPayment")
-	if err = h.service.ConfirmBookingPayment(c.Request.Context(), eventID); err != nil {
-		if errors.Is(err, repository.ErrBookingNotFoundOrAlreadyConfirmed) {
-			zlog.Logger.Error().Err(err).Msg("booking not found or already confirmed")
+
+	var entry dto.JoinWaitlist
+	if err = c.ShouldBindJSON(&entry); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+	entry.EventID = eventID
+
+	joined, err := h.service.JoinWaitlist(c.Request.Context(), &entry)
+	if err != nil {
+		if failBookingLimit(c, err) {
+			return
+		}
+
+		switch {
+		case errors.Is(err, repository.ErrInvalidPlacesCount),
+			errors.Is(err, repository.ErrTicketTypeRequired),
+			errors.Is(err, repository.ErrSeatCountMismatch),
+			errors.Is(err, repository.ErrSeatSelectionRequired),
+			errors.Is(err, repository.ErrNoSuchSeat):
+			zlog.Logger.Error().Err(err).Msg("invalid waitlist entry")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrNoSuchEvent),
+			errors.Is(err, repository.ErrNoSuchTicketType):
+			zlog.Logger.Error().Err(err).Msg("event or ticket type not found")
 			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted),
+			errors.Is(err, repository.ErrEventNotOnSale):
+			zlog.Logger.Error().Err(err).Msg("event is not on sale")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("JoinWaitlist failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("entry", joined).Msg("JoinWaitlist success")
+	response.Created(c, joined)
+}
+
+// events/:id/cancel
+func (h *Handler) CancelEvent(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	// the body is optional: the reason is only added to the attendee message
+	var req dto.CancelEvent
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			zlog.Logger.Error().Err(err).Msg("bind json failed")
+			response.BadRequest(c, err)
 			return
 		}
+	}
 
-		zlog.Logger.Error().Err(err).Msg("ConfirmBookingPayment failed")
-		response.Internal(c, err)
+	zlog.Logger.Info().Interface("eventID", eventID).Interface("req", req).Msg("CancelEvent")
+	cancellation, err := h.service.CancelEvent(c.Request.Context(), eventID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEventNotFound):
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrEventAlreadyCancelled),
+			errors.Is(err, repository.ErrInvalidEventTransition):
+			zlog.Logger.Error().Err(err).Msg("event can not be cancelled")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CancelEvent failed")
+			response.Internal(c, err)
+		}
 		return
 	}
 
-	zlog.Logger.Info().Interface("eventID", eventID).Msg("ConfirmBookingPayment success")
-	response.OK(c, ginext.H{"status": "payment confirmed"})
+	zlog.Logger.Info().Interface("cancellation", cancellation).Msg("CancelEvent success")
+	response.OK(c, cancellation)
 }
 
+// events/:id/status
+func (h *Handler) SetEventStatus(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var req dto.SetEventStatus
+	if err = c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Interface("req", req).Msg("SetEventStatus")
+	event, err := h.service.SetEventStatus(c.Request.Context(), eventID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUnknownEventStatus):
+			zlog.Logger.Error().Err(err).Msg("unknown event status")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrEventNotFound):
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidEventTransition),
+			errors.Is(err, repository.ErrEventAlreadyCancelled),
+			errors.Is(err, repository.ErrEventNotFinished):
+			zlog.Logger.Error().Err(err).Msg("event status can not be changed")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("SetEventStatus failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("event", event).Msg("SetEventStatus success")
+	response.OK(c, event)
+}
+
+// events/:id/reschedule
+func (h *Handler) RescheduleEvent(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var req dto.RescheduleEvent
+	if err = c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Interface("req", req).Msg("RescheduleEvent")
+	result, err := h.service.RescheduleEvent(c.Request.Context(), eventID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidRescheduleTime):
+			zlog.Logger.Error().Err(err).Msg("invalid event time")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrInvalidOptOutDeadline):
+			zlog.Logger.Error().Err(err).Msg("invalid opt-out deadline")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrInvalidSalesWindow):
+			zlog.Logger.Error().Err(err).Msg("invalid sales window")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrEventNotFound):
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted):
+			zlog.Logger.Error().Err(err).Msg("event can not be rescheduled")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("RescheduleEvent failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("result", result).Msg("RescheduleEvent success")
+	response.OK(c, result)
+}
+
+// errEventConfirmGone answers the deprecated events/:id/confirm route.
+var errEventConfirmGone = errors.New("confirming all bookings of an event is no longer supported, " +
+	"confirm each booking with POST /api/bookings/{id}/confirm")
+
+// events/:id/confirm
+// Deprecated: the route confirmed every booking of the event at once, whatever its status.
+// Bookings are confirmed one by one now, so the route only tells old clients where to go.
+func (h *Handler) ConfirmEventBookings(c *ginext.Context) {
+	zlog.Logger.Warn().Str("path", c.Request.URL.Path).Msg("deprecated ConfirmEventBookings called")
+	response.Fail(c, http.StatusGone, errEventConfirmGone)
+}
+
+// bookings/:id/confirm
+func (h *Handler) ConfirmBooking(c *ginext.Context) {
+	bookingID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid booking id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("bookingID", bookingID).Msg("ConfirmBooking")
+	booking, err := h.service.ConfirmBooking(c.Request.Context(), bookingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchBooking):
+			zlog.Logger.Error().Err(err).Msg("booking not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidStatusTransition):
+			zlog.Logger.Error().Err(err).Msg("booking can not be confirmed")
+			response.Fail(c, http.StatusConflict, err)
+		case errors.Is(err, repository.ErrPaymentRequired):
+			zlog.Logger.Error().Err(err).Msg("booking must be paid")
+			response.Fail(c, http.StatusPaymentRequired, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("ConfirmBooking failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("booking", booking).Msg("ConfirmBooking success")
+	response.OK(c, booking)
+}
+
+// bookings/:id/cancel
+func (h *Handler) CancelBooking(c *ginext.Context) {
+	h.cancelBooking(c, repository.ActorUser)
+}
+
+// admin/bookings/:id/cancel
+func (h *Handler) AdminCancelBooking(c *ginext.Context) {
+	h.cancelBooking(c, repository.ActorAdmin)
+}
+
+// cancelBooking cancels on behalf of the actor the route stands for; it is never taken from the request.
+func (h *Handler) cancelBooking(c *ginext.Context, actor string) {
+	bookingID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid booking id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	// the body is optional, it only carries the reason
+	var req dto.CancelBooking
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&req); err != nil {
+			zlog.Logger.Error().Err(err).Msg("bind json failed")
+			response.BadRequest(c, err)
+			return
+		}
+	}
+
+	zlog.Logger.Info().Interface("bookingID", bookingID).Str("actor", actor).Interface("req", req).Msg("CancelBooking")
+	booking, err := h.service.CancelBooking(c.Request.Context(), bookingID, actor, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchBooking):
+			zlog.Logger.Error().Err(err).Msg("booking not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidStatusTransition),
+			errors.Is(err, repository.ErrBookingNotFoundOrAlreadyCancelled):
+			zlog.Logger.Error().Err(err).Msg("booking can not be cancelled")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CancelBooking failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("booking", booking).Msg("CancelBooking success")
+	response.OK(c, booking)
+}
+
+// bookings/:id/opt-out
+func (h *Handler) OptOutBooking(c *ginext.Context) {
+	bookingID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid booking id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("bookingID", bookingID).Msg("OptOutBooking")
+	booking, err := h.service.OptOutBooking(c.Request.Context(), bookingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchBooking):
+			zlog.Logger.Error().Err(err).Msg("booking not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidStatusTransition),
+			errors.Is(err, repository.ErrOptOutNotAvailable):
+			zlog.Logger.Error().Err(err).Msg("booking can not be opted out")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("OptOutBooking failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("booking", booking).Msg("OptOutBooking success")
+	response.OK(c, booking)
+}
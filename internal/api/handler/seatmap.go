@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// seat-maps
+func (h *Handler) CreateSeatMap(c *ginext.Context) {
+	var req dto.CreateSeatMap
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	seatMap, err := h.service.CreateSeatMap(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidSeatMap):
+			zlog.Logger.Error().Err(err).Msg("invalid seat map")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrNoSuchVenue),
+			errors.Is(err, repository.ErrEventNotFound):
+			zlog.Logger.Error().Err(err).Msg("venue or event not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrSeatMapExists):
+			zlog.Logger.Error().Err(err).Msg("seat map already exists")
+			response.Fail(c, http.StatusConflict, err)
+		case errors.Is(err, repository.ErrSeatMapTooLarge),
+			errors.Is(err, repository.ErrSeatsTakenWithoutMap):
+			zlog.Logger.Error().Err(err).Msg("seat map does not fit the events")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CreateSeatMap failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("seatMapID", seatMap.ID).Int("seats", len(seatMap.Seats)).Msg("CreateSeatMap success")
+	response.Created(c, seatMap)
+}
+
+// seat-maps/:id
+func (h *Handler) GetSeatMap(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid seat map id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	seatMap, err := h.service.GetSeatMap(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchSeatMap) {
+			zlog.Logger.Error().Err(err).Msg("seat map not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get seat map")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("seatMapID", id).Msg("successfully handled GET seat map")
+	response.OK(c, seatMap)
+}
+
+// events/:id/seats
+func (h *Handler) GetEventSeats(c *ginext.Context) {
+	eventID, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid event id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	seats, err := h.service.GetEventSeats(c.Request.Context(), eventID)
+	if err != nil {
+		if errors.Is(err, repository.ErrEventNotFound) {
+			zlog.Logger.Error().Err(err).Msg("event not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get event seats")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Msg("successfully handled GET event seats")
+	response.OK(c, seats)
+}
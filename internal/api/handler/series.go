@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// series
+func (h *Handler) CreateEventSeries(c *ginext.Context) {
+	var req dto.CreateEventSeries
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	series, err := h.service.CreateEventSeries(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidRecurrence),
+			errors.Is(err, repository.ErrInvalidSeries),
+			errors.Is(err, repository.ErrInvalidPaymentWindow),
+			errors.Is(err, repository.ErrInvalidEventStatus),
+			errors.Is(err, repository.ErrInvalidSalesWindow):
+			zlog.Logger.Error().Err(err).Msg("invalid event series")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrNoSuchVenue):
+			zlog.Logger.Error().Err(err).Msg("venue not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrSeatMapTooLarge):
+			zlog.Logger.Error().Err(err).Msg("occurrences are smaller than the seat map of the venue")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("CreateEventSeries failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("seriesID", series.ID).Int("occurrences", len(series.Occurrences)).
+		Msg("CreateEventSeries success")
+	response.Created(c, series)
+}
+
+// series/:id
+func (h *Handler) GetEventSeries(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid series id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	series, err := h.service.GetEventSeries(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchSeries) {
+			zlog.Logger.Error().Err(err).Msg("event series not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get event series")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("seriesID", id).Msg("successfully handled GET event series")
+	response.OK(c, series)
+}
+
+// series/:id
+func (h *Handler) UpdateEventSeries(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid series id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var upd dto.UpdateEventSeries
+	if err = c.ShouldBindJSON(&upd); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	result, err := h.service.UpdateEventSeries(c.Request.Context(), id, &upd)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchSeries):
+			zlog.Logger.Error().Err(err).Msg("event series not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidSeriesScope),
+			errors.Is(err, repository.ErrInvalidSeries),
+			errors.Is(err, repository.ErrInvalidPaymentWindow),
+			errors.Is(err, repository.ErrInvalidSalesWindow):
+			zlog.Logger.Error().Err(err).Msg("invalid series update")
+			response.BadRequest(c, err)
+		case errors.Is(err, repository.ErrOccurrenceHasBookings),
+			errors.Is(err, repository.ErrCapacityBelowTicketTypes),
+			errors.Is(err, repository.ErrSeatMapTooLarge),
+			errors.Is(err, repository.ErrEventCancelled),
+			errors.Is(err, repository.ErrEventCompleted):
+			zlog.Logger.Error().Err(err).Msg("occurrence can not be updated")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("UpdateEventSeries failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("seriesID", id).Int("updated", len(result.Updated)).Int("skipped", len(result.Skipped)).
+		Msg("UpdateEventSeries success")
+	response.OK(c, result)
+}
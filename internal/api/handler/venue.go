@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"github.com/K1la/event-booker/internal/api/response"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+)
+
+// venues
+func (h *Handler) CreateVenue(c *ginext.Context) {
+	var req dto.CreateVenue
+	if err := c.ShouldBindJSON(&req); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	venue, err := h.service.CreateVenue(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidVenue) {
+			zlog.Logger.Error().Err(err).Msg("invalid venue")
+			response.BadRequest(c, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("CreateVenue failed")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("venue", venue).Msg("CreateVenue success")
+	response.Created(c, venue)
+}
+
+// venues
+func (h *Handler) GetVenues(c *ginext.Context) {
+	venues, err := h.service.GetVenues(c.Request.Context())
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("could not get venues")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Int("count", len(venues)).Msg("successfully handled GET venues")
+	response.OK(c, venues)
+}
+
+// venues/:id
+func (h *Handler) GetVenueByID(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid venue id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	venue, err := h.service.GetVenueByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchVenue) {
+			zlog.Logger.Error().Err(err).Msg("venue not found")
+			response.Fail(c, http.StatusNotFound, err)
+			return
+		}
+
+		zlog.Logger.Error().Err(err).Msg("could not get venue")
+		response.Internal(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Interface("venueID", id).Msg("successfully handled GET venue")
+	response.OK(c, venue)
+}
+
+// venues/:id
+func (h *Handler) UpdateVenue(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid venue id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	var upd dto.UpdateVenue
+	if err = c.ShouldBindJSON(&upd); err != nil {
+		zlog.Logger.Error().Err(err).Msg("bind json failed")
+		response.BadRequest(c, err)
+		return
+	}
+
+	venue, err := h.service.UpdateVenue(c.Request.Context(), id, &upd)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchVenue):
+			zlog.Logger.Error().Err(err).Msg("venue not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrInvalidVenue):
+			zlog.Logger.Error().Err(err).Msg("invalid venue")
+			response.BadRequest(c, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("UpdateVenue failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("venue", venue).Msg("UpdateVenue success")
+	response.OK(c, venue)
+}
+
+// venues/:id
+func (h *Handler) DeleteVenue(c *ginext.Context) {
+	id, err := parseUUIDParam(c, "id")
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("missing or invalid venue id")
+		response.BadRequest(c, err)
+		return
+	}
+
+	if err = h.service.DeleteVenue(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoSuchVenue):
+			zlog.Logger.Error().Err(err).Msg("venue not found")
+			response.Fail(c, http.StatusNotFound, err)
+		case errors.Is(err, repository.ErrVenueInUse):
+			zlog.Logger.Error().Err(err).Msg("venue has events")
+			response.Fail(c, http.StatusConflict, err)
+		default:
+			zlog.Logger.Error().Err(err).Msg("DeleteVenue failed")
+			response.Internal(c, err)
+		}
+		return
+	}
+
+	zlog.Logger.Info().Interface("venueID", id).Msg("DeleteVenue success")
+	response.OK(c, ginext.H{"status": "venue deleted"})
+}
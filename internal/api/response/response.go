@@ -11,6 +11,7 @@ type Success struct {
 
 type Error struct {
 	Message string `json:"error"`
+	Code    string `json:"code,omitempty"`
 }
 
 func JSON(c *ginext.Context, status int, data interface{}) {
@@ -36,3 +37,8 @@ func BadRequest(c *ginext.Context, err error) {
 func Fail(c *ginext.Context, status int, err error) {
 	JSON(c, status, Error{Message: err.Error()})
 }
+
+// FailCode is Fail with a machine-readable code, for errors clients need to tell apart.
+func FailCode(c *ginext.Context, status int, code string, err error) {
+	JSON(c, status, Error{Message: err.Error(), Code: code})
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/K1la/event-booker/internal/api/handler"
+	"github.com/gin-gonic/gin"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -9,7 +10,9 @@ import (
 	"github.com/wb-go/wbf/ginext"
 )
 
-func New(handler *handler.Handler) *ginext.Engine {
+// New builds the API routes. checkout serves the payment provider's own pages under /payments/;
+// it is nil when the provider hosts them itself or payments are off, and then nothing is mounted there.
+func New(handler *handler.Handler, checkout http.Handler) *ginext.Engine {
 	e := ginext.New("")
 	e.Use(ginext.Recovery(), ginext.Logger()) //customLoggerMiddleware())
 
@@ -17,13 +20,104 @@ func New(handler *handler.Handler) *ginext.Engine {
 	api := e.Group("/api/events")
 	{
 		api.POST("", handler.CreateEvent)
-		api.POST("/:id/book", handler.CreateBooking)
-		api.POST("/:id/confirm", handler.ConfirmBookingPayment)
-		//api.POST("/:id", handler.CancelBooking)
+		api.POST("/:id/ticket-types", handler.CreateTicketType)
+		api.POST("/:id/book", handler.Idempotent("create_booking"), handler.CreateBooking)
+		api.POST("/:id/waitlist", handler.JoinWaitlist)
+		api.POST("/:id/holds", handler.CreateHold)
+		api.POST("/:id/cancel", handler.CancelEvent)
+		api.POST("/:id/reschedule", handler.RescheduleEvent)
+		api.POST("/:id/status", handler.SetEventStatus)
+		api.POST("/:id/confirm", handler.ConfirmEventBookings) // deprecated, answers 410 Gone
 
 		api.GET("/:id", handler.GetEventByID)
 		api.GET("", handler.GetEvents)
+		api.GET("/:id/notifications", handler.GetEventNotifications)
+		api.GET("/:id/ticket-types", handler.GetTicketTypes)
+		api.GET("/:id/seats", handler.GetEventSeats)
 
+		api.PATCH("/:id", handler.UpdateEvent)
+		api.DELETE("/:id", handler.DeleteEvent)
+
+	}
+
+	bookings := e.Group("/api/bookings")
+	{
+		bookings.POST("/:id/confirm", handler.ConfirmBooking)
+		bookings.POST("/:id/cancel", handler.CancelBooking)
+		bookings.POST("/:id/opt-out", handler.OptOutBooking)
+		bookings.POST("/:id/pay", handler.PayBooking)
+
+		bookings.GET("/:id/history", handler.GetBookingHistory)
+		bookings.GET("/:id/refunds", handler.GetBookingRefunds)
+	}
+
+	admin := e.Group("/api/admin")
+	{
+		admin.POST("/bookings/:id/cancel", handler.AdminCancelBooking)
+	}
+
+	holds := e.Group("/api/holds")
+	{
+		holds.POST("/:id/book", handler.ConvertHold)
+
+		holds.GET("/:id", handler.GetHold)
+
+		holds.DELETE("/:id", handler.ReleaseHold)
+	}
+
+	seatMaps := e.Group("/api/seat-maps")
+	{
+		seatMaps.POST("", handler.CreateSeatMap)
+
+		seatMaps.GET("/:id", handler.GetSeatMap)
+	}
+
+	venues := e.Group("/api/venues")
+	{
+		venues.POST("", handler.CreateVenue)
+
+		venues.GET("", handler.GetVenues)
+		venues.GET("/:id", handler.GetVenueByID)
+
+		venues.PATCH("/:id", handler.UpdateVenue)
+
+		venues.DELETE("/:id", handler.DeleteVenue)
+	}
+
+	pools := e.Group("/api/capacity-pools")
+	{
+		pools.POST("", handler.CreateCapacityPool)
+
+		pools.GET("", handler.GetCapacityPools)
+		pools.GET("/:id", handler.GetCapacityPoolByID)
+
+		pools.PATCH("/:id", handler.UpdateCapacityPool)
+	}
+
+	series := e.Group("/api/series")
+	{
+		series.POST("", handler.CreateEventSeries)
+
+		series.GET("/:id", handler.GetEventSeries)
+
+		series.PATCH("/:id", handler.UpdateEventSeries)
+	}
+
+	promoCodes := e.Group("/api/promo-codes")
+	{
+		promoCodes.POST("", handler.CreatePromoCode)
+
+		promoCodes.GET("", handler.GetPromoCodes)
+		promoCodes.GET("/:id/report", handler.GetPromoCodeReport)
+	}
+
+	payments := e.Group("/api/payments")
+	{
+		payments.POST("/webhook", handler.PaymentWebhook)
+	}
+
+	if checkout != nil {
+		e.Any("/payments/*path", gin.WrapH(checkout))
 	}
 
 	// // Frontend: serve files from ./web
@@ -34,6 +34,15 @@ func Init() *Config {
 
 	val, _ := os.LookupEnv("DB_PASSWORD")
 	cfg.Postgres.Password = val
+	if env, ok := os.LookupEnv("APP_ENV"); ok {
+		cfg.Env = env
+	}
+	if provider, ok := os.LookupEnv("PAYMENT_PROVIDER"); ok {
+		cfg.Payment.Provider = provider
+	}
+	if secret, ok := os.LookupEnv("PAYMENT_WEBHOOK_SECRET"); ok {
+		cfg.Payment.WebhookSecret = secret
+	}
 	zlog.Logger.Info().Msgf("BEFORE cfg rabbitmq: %+v", cfg.RabbitMQ)
 	if cfg.RabbitMQ.Port == "" || cfg.RabbitMQ.Host == "" {
 		cfg.RabbitMQ.Port, _ = os.LookupEnv("RABBITMQ_PORT")
@@ -1,9 +1,24 @@
 package config
 
+// EnvDev is the environment of local development, the only one where test doubles such as
+// the fake payment provider may run.
+const EnvDev = "dev"
+
 type Config struct {
-	Postgres   Postgres   `mapstructure:"postgres"`
-	HTTPServer HTTPServer `mapstructure:"http_server"`
-	RabbitMQ   RabbitMQ   `mapstructure:"rabbit_mq"`
+	Env         string      `mapstructure:"env"`
+	Postgres    Postgres    `mapstructure:"postgres"`
+	HTTPServer  HTTPServer  `mapstructure:"http_server"`
+	RabbitMQ    RabbitMQ    `mapstructure:"rabbit_mq"`
+	Outbox      Outbox      `mapstructure:"outbox"`
+	Sweeper     Sweeper     `mapstructure:"expiry_sweeper"`
+	Idempotency Idempotency `mapstructure:"idempotency"`
+	Notifier    Notifier    `mapstructure:"notifier"`
+	Completer   Completer   `mapstructure:"event_completer"`
+	Sales       Sales       `mapstructure:"sales"`
+	Payment     Payment     `mapstructure:"payment"`
+	Refunds     Refunds     `mapstructure:"refunds"`
+	Limits      Limits      `mapstructure:"booking_limits"`
+	Holds       Holds       `mapstructure:"seat_holds"`
 }
 
 type Postgres struct {
@@ -24,3 +39,68 @@ type RabbitMQ struct {
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
 }
+
+type Outbox struct {
+	RelayInterval int `mapstructure:"relay_interval"`
+	BatchSize     int `mapstructure:"batch_size"`
+}
+
+type Sweeper struct {
+	Enabled   bool `mapstructure:"enabled"`
+	Interval  int  `mapstructure:"interval"`
+	BatchSize int  `mapstructure:"batch_size"`
+}
+
+type Idempotency struct {
+	TTL             int `mapstructure:"ttl"`
+	CleanupInterval int `mapstructure:"cleanup_interval"`
+	Lease           int `mapstructure:"lease"`
+}
+
+type Notifier struct {
+	Interval    int `mapstructure:"interval"`
+	BatchSize   int `mapstructure:"batch_size"`
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+type Completer struct {
+	Interval int `mapstructure:"interval"`
+}
+
+type Sales struct {
+	CutoffMinutes int `mapstructure:"cutoff_minutes"`
+}
+
+type Payment struct {
+	Provider      string `mapstructure:"provider"` // empty turns payments off
+	PublicURL     string `mapstructure:"public_url"`
+	WebhookURL    string `mapstructure:"webhook_url"`
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+type Refunds struct {
+	Interval    int          `mapstructure:"interval"`
+	BatchSize   int          `mapstructure:"batch_size"`
+	MaxAttempts int          `mapstructure:"max_attempts"`
+	Policy      []RefundRule `mapstructure:"policy"`
+}
+
+// RefundRule refunds Percent of the payment when the booking is cancelled
+// at least MinHoursBefore hours before the event starts.
+type RefundRule struct {
+	MinHoursBefore int `mapstructure:"min_hours_before"`
+	Percent        int `mapstructure:"percent"`
+}
+
+// Limits keep a single user from holding an event's capacity. Zero turns a limit off.
+type Limits struct {
+	MaxSeatsPerBooking      int `mapstructure:"max_seats_per_booking"`
+	MaxSeatsPerUserPerEvent int `mapstructure:"max_seats_per_user_per_event"`
+	MaxPendingPerUser       int `mapstructure:"max_pending_per_user"`
+}
+
+type Holds struct {
+	TTL           int `mapstructure:"ttl"`
+	SweepInterval int `mapstructure:"sweep_interval"`
+	BatchSize     int `mapstructure:"batch_size"`
+}
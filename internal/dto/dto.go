@@ -6,28 +6,243 @@ import (
 )
 
 type Booking struct {
-	ID         uuid.UUID `json:"id"`
-	EventID    uuid.UUID `json:"event_id"`
-	EventTitle string    `json:"event_title"`
-	TelegramID int       `json:"telegram_id"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID            uuid.UUID   `json:"id"`
+	EventID       uuid.UUID   `json:"event_id"`
+	EventTitle    string      `json:"event_title"`
+	TelegramID    int         `json:"telegram_id"`
+	PlacesCount   int         `json:"places_count"`
+	TicketTypeID  *uuid.UUID  `json:"ticket_type_id,omitempty"`
+	PriceMinor    int64       `json:"price_minor"`
+	Currency      string      `json:"currency,omitempty"`
+	DiscountMinor int64       `json:"discount_minor,omitempty"`
+	SeatIDs       []uuid.UUID `json:"seat_ids,omitempty"`
+	Status        string      `json:"status"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
 }
 
 type QueueMessage struct {
-	BookingID   uuid.UUID `json:"booking_id"`
-	PlacesCount int       `json:"places_count"`
+	BookingID uuid.UUID `json:"booking_id"`
 }
 
 type CreateEvent struct {
-	Title      string    `json:"title"`
-	EventAt    time.Time `json:"event_at"`
-	TotalSeats int       `json:"total_seats"`
+	Title                string     `json:"title"`
+	EventAt              time.Time  `json:"event_at"`
+	TotalSeats           int        `json:"total_seats"`
+	PaymentWindowMinutes int        `json:"payment_window_minutes,omitempty"`
+	Status               string     `json:"status,omitempty"`   // draft (default) or published
+	VenueID              *uuid.UUID `json:"venue_id,omitempty"` // TotalSeats defaults to the capacity of the venue
+	PoolID               *uuid.UUID `json:"pool_id,omitempty"`  // bookings take seats of the shared pool too
+	// SaleOpensAt defaults to the creation time, SaleClosesAt to the configured cutoff before EventAt.
+	SaleOpensAt  *time.Time `json:"sale_opens_at,omitempty"`
+	SaleClosesAt *time.Time `json:"sale_closes_at,omitempty"`
 }
 
+// UpdateEvent changes only the fields that are set. Version must be the version
+// of the event the client has seen, otherwise the update is rejected.
+type UpdateEvent struct {
+	Title                *string    `json:"title,omitempty"`
+	EventAt              *time.Time `json:"event_at,omitempty"`
+	TotalSeats           *int       `json:"total_seats,omitempty"`
+	PaymentWindowMinutes *int       `json:"payment_window_minutes,omitempty"`
+	SaleOpensAt          *time.Time `json:"sale_opens_at,omitempty"`
+	SaleClosesAt         *time.Time `json:"sale_closes_at,omitempty"`
+	VenueID              *uuid.UUID `json:"venue_id,omitempty"`
+	Version              int        `json:"version"`
+}
+
+// CreateBooking takes seats of the ticket type; it is required once the event has ticket types.
 type CreateBooking struct {
-	EventID     uuid.UUID `json:"event_id,omitempty"`
-	TelegramID  int       `json:"telegram_id"`
-	PlacesCount int       `json:"places_count"`
+	EventID      uuid.UUID  `json:"event_id,omitempty"`
+	TelegramID   int        `json:"telegram_id"`
+	PlacesCount  int        `json:"places_count"`
+	TicketTypeID *uuid.UUID `json:"ticket_type_id,omitempty"`
+	PromoCode    string     `json:"promo_code,omitempty"`
+	// SeatIDs picks exact seats of the event's seat map; places_count may then be omitted.
+	SeatIDs []uuid.UUID `json:"seat_ids,omitempty"`
+}
+
+type JoinWaitlist struct {
+	EventID      uuid.UUID  `json:"event_id,omitempty"`
+	TelegramID   int        `json:"telegram_id"`
+	PlacesCount  int        `json:"places_count"`
+	TicketTypeID *uuid.UUID `json:"ticket_type_id,omitempty"`
+	// SeatIDs are the seats to wait for, required once the event has a seat map.
+	SeatIDs []uuid.UUID `json:"seat_ids,omitempty"`
+}
+
+type CreateTicketType struct {
+	Name       string `json:"name"`
+	PriceMinor int64  `json:"price_minor"`
+	Currency   string `json:"currency"`
+	Capacity   int    `json:"capacity"`
+	// PoolID is the shared pool the seats of the type are taken from, in addition to the pool of the event.
+	PoolID *uuid.UUID `json:"pool_id,omitempty"`
+}
+
+// StatusChange describes who moved a booking to a new status and why.
+type StatusChange struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// CancelBooking carries the optional reason of a cancellation. Who cancels is decided by the route.
+type CancelBooking struct {
+	Reason string `json:"reason"`
+}
+
+type CancelEvent struct {
+	Reason string `json:"reason"`
+}
+
+// RescheduleEvent moves the event to EventAt. Attendees may cancel their bookings
+// until OptOutDeadline; when it is not set a default window is used.
+type RescheduleEvent struct {
+	EventAt        time.Time  `json:"event_at"`
+	OptOutDeadline *time.Time `json:"opt_out_deadline,omitempty"`
+}
+
+type SetEventStatus struct {
+	Status string `json:"status"`
+}
+
+// PaymentIntentRequest asks a payment provider to collect the price of a booking.
+type PaymentIntentRequest struct {
+	BookingID   uuid.UUID
+	AmountMinor int64
+	Currency    string
+	Description string
+}
+
+// PaymentIntent is the provider's side of a payment: its id and the page where the attendee pays.
+type PaymentIntent struct {
+	ProviderPaymentID string
+	CheckoutURL       string
+}
+
+// PaymentWebhook is the outcome of a payment reported by the provider.
+type PaymentWebhook struct {
+	ProviderPaymentID string `json:"payment_id"`
+	Status            string `json:"status"` // succeeded or failed
+}
+
+// RefundRequest asks the provider to return part of a payment. IdempotencyKey is the refund's own id,
+// so a refund retried after a lost answer is not paid out twice.
+type RefundRequest struct {
+	IdempotencyKey    string
+	ProviderPaymentID string
+	AmountMinor       int64
+	Currency          string
+	Reason            string
+}
+
+type RefundResult struct {
+	ProviderRefundID string
+}
+
+// CreatePromoCode describes a discount code. DiscountValue is a percentage for "percent" codes
+// and an amount in minor units of Currency for "fixed" ones. Empty EventIDs or TicketTypeIDs
+// mean no restriction, a nil MaxUses means unlimited uses.
+type CreatePromoCode struct {
+	Code          string      `json:"code"`
+	DiscountType  string      `json:"discount_type"`
+	DiscountValue int64       `json:"discount_value"`
+	Currency      string      `json:"currency,omitempty"`
+	MaxUses       *int        `json:"max_uses,omitempty"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty"`
+	EventIDs      []uuid.UUID `json:"event_ids,omitempty"`
+	TicketTypeIDs []uuid.UUID `json:"ticket_type_ids,omitempty"`
+}
+
+type CreateHold struct {
+	EventID      uuid.UUID  `json:"event_id,omitempty"`
+	TelegramID   int        `json:"telegram_id"`
+	PlacesCount  int        `json:"places_count"`
+	TicketTypeID *uuid.UUID `json:"ticket_type_id,omitempty"`
+	// SeatIDs picks exact seats of the event's seat map, like in CreateBooking.
+	SeatIDs []uuid.UUID `json:"seat_ids,omitempty"`
+}
+
+type ConvertHold struct {
+	PromoCode string `json:"promo_code,omitempty"`
+}
+
+// Recurrence says when the occurrences of a series happen: every Interval days, weeks or months
+// starting from the first occurrence. Exactly one of Until and Count ends the series.
+type Recurrence struct {
+	Frequency string     `json:"frequency"`          // daily, weekly or monthly
+	Interval  int        `json:"interval,omitempty"` // 1 by default
+	Weekdays  []int      `json:"weekdays,omitempty"` // weekly only: 0 is Sunday, 6 is Saturday; the first occurrence's weekday by default
+	Until     *time.Time `json:"until,omitempty"`
+	Count     *int       `json:"count,omitempty"`
+}
+
+type CreateEventSeries struct {
+	Title                string     `json:"title"`
+	StartsAt             time.Time  `json:"starts_at"`
+	TotalSeats           int        `json:"total_seats,omitempty"` // the capacity of the venue by default
+	PaymentWindowMinutes int        `json:"payment_window_minutes,omitempty"`
+	Status               string     `json:"status,omitempty"` // of the occurrences: draft (default) or published
+	VenueID              *uuid.UUID `json:"venue_id,omitempty"`
+	Recurrence           Recurrence `json:"recurrence"`
+}
+
+// UpdateEventSeries changes the fields that are set on the occurrences picked by Scope:
+// "one" changes OccurrenceID only, "following" changes it and the later ones, "all" changes every occurrence.
+type UpdateEventSeries struct {
+	Scope                string     `json:"scope"`
+	OccurrenceID         *uuid.UUID `json:"occurrence_id,omitempty"`
+	Title                *string    `json:"title,omitempty"`
+	TotalSeats           *int       `json:"total_seats,omitempty"`
+	PaymentWindowMinutes *int       `json:"payment_window_minutes,omitempty"`
+	ShiftMinutes         *int       `json:"shift_minutes,omitempty"` // moves the occurrences, the sales close moves with them
+}
+
+type CreateVenue struct {
+	Name     string `json:"name"`
+	Address  string `json:"address,omitempty"`
+	Timezone string `json:"timezone"`
+	Capacity int    `json:"capacity"`
+}
+
+type UpdateVenue struct {
+	Name     *string `json:"name,omitempty"`
+	Address  *string `json:"address,omitempty"`
+	Timezone *string `json:"timezone,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+type CreateCapacityPool struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+}
+
+type UpdateCapacityPool struct {
+	Name     *string `json:"name,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+// CreateSeatMap describes the layout of exactly one of a venue or an event, section by section and row by row.
+type CreateSeatMap struct {
+	VenueID  *uuid.UUID    `json:"venue_id,omitempty"`
+	EventID  *uuid.UUID    `json:"event_id,omitempty"`
+	Name     string        `json:"name"`
+	Sections []SeatSection `json:"sections"`
+}
+
+type SeatSection struct {
+	Name string    `json:"name"`
+	Rows []SeatRow `json:"rows"`
+}
+
+type SeatRow struct {
+	Name  string     `json:"name"`
+	Seats []SeatSpec `json:"seats"`
+}
+
+type SeatSpec struct {
+	Number     int  `json:"number"`
+	Wheelchair bool `json:"wheelchair,omitempty"`
+	Companion  bool `json:"companion,omitempty"`
 }
@@ -1,27 +1,339 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Event struct {
+	ID                   uuid.UUID    `json:"id"`
+	Title                string       `json:"title"`
+	TotalSeats           int          `json:"total_seats"`
+	AvailableSeats       int          `json:"available_seats"`
+	HeldSeats            int          `json:"held_seats"` // seats of active holds, already taken from AvailableSeats
+	PaymentWindowMinutes int          `json:"payment_window_minutes"`
+	Version              int          `json:"version"`
+	Status               string       `json:"status"`
+	EventAt              time.Time    `json:"event_at"`
+	SaleOpensAt          time.Time    `json:"sale_opens_at"`
+	SaleClosesAt         time.Time    `json:"sale_closes_at"`
+	CancelledAt          *time.Time   `json:"cancelled_at,omitempty"`
+	SeriesID             *uuid.UUID   `json:"series_id,omitempty"`
+	SeriesPosition       *int         `json:"series_position,omitempty"` // 1-based place of the occurrence in its series
+	VenueID              *uuid.UUID   `json:"venue_id,omitempty"`
+	Timezone             string       `json:"timezone,omitempty"` // of the venue, the event times are shown in it
+	PoolID               *uuid.UUID   `json:"pool_id,omitempty"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+	Bookings             []Booking    `json:"bookings,omitempty"`
+	TicketTypes          []TicketType `json:"ticket_types,omitempty"`
+	Seats                []EventSeat  `json:"seats,omitempty"` // of the seat map, for events with reserved seating
+}
+
+// Location returns the timezone of the event's venue, or nil if the event has no venue.
+func (e *Event) Location() *time.Location {
+	if e.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// MarshalJSON shows the event times in the timezone of the venue, so clients see the local time of the event.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	local := event(e)
+	if loc := e.Location(); loc != nil {
+		local.EventAt = local.EventAt.In(loc)
+		local.SaleOpensAt = local.SaleOpensAt.In(loc)
+		local.SaleClosesAt = local.SaleClosesAt.In(loc)
+		local.CreatedAt = local.CreatedAt.In(loc)
+		local.UpdatedAt = local.UpdatedAt.In(loc)
+		if local.CancelledAt != nil {
+			cancelledAt := local.CancelledAt.In(loc)
+			local.CancelledAt = &cancelledAt
+		}
+	}
+	return json.Marshal(local)
+}
+
+// TicketType is a category of seats of an event with its own price and capacity.
+// Its seats are part of the event's seats, so booking one takes a seat of both.
+type TicketType struct {
+	ID         uuid.UUID  `json:"id"`
+	EventID    uuid.UUID  `json:"event_id"`
+	Name       string     `json:"name"`
+	PriceMinor int64      `json:"price_minor"`
+	Currency   string     `json:"currency"`
+	Capacity   int        `json:"capacity"`
+	Available  int        `json:"available"`
+	PoolID     *uuid.UUID `json:"pool_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type Booking struct {
+	ID            uuid.UUID   `json:"id"`
+	EventID       uuid.UUID   `json:"event_id"`
+	PlacesCount   int         `json:"places_count"`
+	Status        string      `json:"status"`
+	TelegramID    int         `json:"telegram_id,omitempty"`
+	TicketTypeID  *uuid.UUID  `json:"ticket_type_id,omitempty"`
+	PriceMinor    int64       `json:"price_minor"`
+	Currency      string      `json:"currency,omitempty"`
+	PromoCodeID   *uuid.UUID  `json:"promo_code_id,omitempty"`
+	DiscountMinor int64       `json:"discount_minor,omitempty"`
+	SeatIDs       []uuid.UUID `json:"seat_ids,omitempty"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type WaitlistEntry struct {
+	ID           uuid.UUID   `json:"id"`
+	EventID      uuid.UUID   `json:"event_id"`
+	TelegramID   int         `json:"telegram_id"`
+	PlacesCount  int         `json:"places_count"`
+	TicketTypeID *uuid.UUID  `json:"ticket_type_id,omitempty"`
+	SeatIDs      []uuid.UUID `json:"seat_ids,omitempty"`
+	Status       string      `json:"status"`
+	BookingID    *uuid.UUID  `json:"booking_id,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type OutboxMessage struct {
+	ID        uuid.UUID `json:"id"`
+	BookingID uuid.UUID `json:"booking_id"`
+	DeliverAt time.Time `json:"deliver_at"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	StatusCode  int // zero while the original request is still in progress
+	Response    []byte
+	CreatedAt   time.Time
+}
+
+type BookingHistoryEntry struct {
+	ID         int64     `json:"id"`
+	BookingID  uuid.UUID `json:"booking_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// EventCancellation is the outcome of calling an event off.
+type EventCancellation struct {
+	Event               *Event `json:"event"`
+	CancelledBookings   int    `json:"cancelled_bookings"`
+	CancelledWaitlist   int    `json:"cancelled_waitlist"`
+	QueuedNotifications int    `json:"queued_notifications"`
+}
+
+type EventReschedule struct {
 	ID             uuid.UUID `json:"id"`
-	Title          string    `json:"title"`
-	TotalSeats     int       `json:"total_seats"`
-	AvailableSeats int       `json:"available_seats"`
-	EventAt        time.Time `json:"event_at"`
+	EventID        uuid.UUID `json:"event_id"`
+	OldEventAt     time.Time `json:"old_event_at"`
+	NewEventAt     time.Time `json:"new_event_at"`
+	OptOutDeadline time.Time `json:"opt_out_deadline"`
 	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Bookings       []Booking `json:"bookings,omitempty"`
 }
 
-type Booking struct {
-	ID          uuid.UUID `json:"id"`
-	EventID     uuid.UUID `json:"event_id"`
-	PlacesCount int       `json:"places_count"`
-	Status      string    `json:"status"`
-	TelegramID  int       `json:"telegram_id,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+// RescheduleResult is the outcome of moving an event to a new time.
+type RescheduleResult struct {
+	Event               *Event           `json:"event"`
+	Reschedule          *EventReschedule `json:"reschedule"`
+	QueuedNotifications int              `json:"queued_notifications"`
+}
+
+type Notification struct {
+	ID         uuid.UUID  `json:"id"`
+	EventID    uuid.UUID  `json:"event_id"`
+	TelegramID int        `json:"telegram_id"`
+	Text       string     `json:"text"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	LastError  string     `json:"last_error,omitempty"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// NotificationReport shows how delivery of an event's notifications is going.
+// Failures lists the recipients that could not be reached after all attempts.
+type NotificationReport struct {
+	EventID  uuid.UUID       `json:"event_id"`
+	Total    int             `json:"total"`
+	Queued   int             `json:"queued"`
+	Sent     int             `json:"sent"`
+	Failed   int             `json:"failed"`
+	Failures []*Notification `json:"failures"`
+}
+
+type Payment struct {
+	ID                uuid.UUID `json:"id"`
+	BookingID         uuid.UUID `json:"booking_id"`
+	Provider          string    `json:"provider"`
+	ProviderPaymentID string    `json:"provider_payment_id"`
+	AmountMinor       int64     `json:"amount_minor"`
+	Currency          string    `json:"currency"`
+	Status            string    `json:"status"`
+	CheckoutURL       string    `json:"checkout_url"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// Refund returns the share of a payment given by the refund policy when a paid booking is cancelled.
+type Refund struct {
+	ID                uuid.UUID  `json:"id"`
+	BookingID         uuid.UUID  `json:"booking_id"`
+	PaymentID         uuid.UUID  `json:"payment_id"`
+	Provider          string     `json:"provider"`
+	ProviderPaymentID string     `json:"provider_payment_id"`
+	ProviderRefundID  string     `json:"provider_refund_id,omitempty"`
+	AmountMinor       int64      `json:"amount_minor"`
+	Currency          string     `json:"currency"`
+	Percent           int        `json:"percent"`
+	Reason            string     `json:"reason"`
+	Status            string     `json:"status"`
+	Attempts          int        `json:"attempts"`
+	LastError         string     `json:"last_error,omitempty"`
+	RefundedAt        *time.Time `json:"refunded_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type PromoCode struct {
+	ID            uuid.UUID   `json:"id"`
+	Code          string      `json:"code"`
+	DiscountType  string      `json:"discount_type"`
+	DiscountValue int64       `json:"discount_value"`
+	Currency      string      `json:"currency,omitempty"`
+	MaxUses       *int        `json:"max_uses,omitempty"`
+	UsedCount     int         `json:"used_count"`
+	ExpiresAt     *time.Time  `json:"expires_at,omitempty"`
+	EventIDs      []uuid.UUID `json:"event_ids"`
+	TicketTypeIDs []uuid.UUID `json:"ticket_type_ids"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// PromoCodeReport shows how a promo code has been used. Released redemptions belong to bookings
+// that expired or were cancelled before payment; their uses went back to the code.
+type PromoCodeReport struct {
+	PromoCode *PromoCode       `json:"promo_code"`
+	Remaining *int             `json:"remaining,omitempty"`
+	Applied   int              `json:"applied"`
+	Confirmed int              `json:"confirmed"`
+	Released  int              `json:"released"`
+	Discounts []DiscountAmount `json:"discounts"`
+}
+
+// DiscountAmount is the discount given by the applied redemptions in one currency.
+type DiscountAmount struct {
+	Currency    string `json:"currency"`
+	AmountMinor int64  `json:"amount_minor"`
+}
+
+// SeatHold keeps seats for a few minutes while the user fills in the booking details.
+// It becomes a booking or lapses, and the seats go back to the event.
+type SeatHold struct {
+	ID           uuid.UUID   `json:"id"`
+	EventID      uuid.UUID   `json:"event_id"`
+	TicketTypeID *uuid.UUID  `json:"ticket_type_id,omitempty"`
+	TelegramID   int         `json:"telegram_id,omitempty"`
+	PlacesCount  int         `json:"places_count"`
+	SeatIDs      []uuid.UUID `json:"seat_ids,omitempty"`
+	Status       string      `json:"status"`
+	BookingID    *uuid.UUID  `json:"booking_id,omitempty"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// EventSeries is the template of a recurring event. Its occurrences are ordinary events
+// that point back to the series.
+type EventSeries struct {
+	ID                   uuid.UUID  `json:"id"`
+	Title                string     `json:"title"`
+	TotalSeats           int        `json:"total_seats"`
+	PaymentWindowMinutes int        `json:"payment_window_minutes"`
+	Frequency            string     `json:"frequency"`
+	Interval             int        `json:"interval"`
+	Weekdays             []int      `json:"weekdays,omitempty"`
+	StartsAt             time.Time  `json:"starts_at"`
+	Until                *time.Time `json:"until,omitempty"`
+	Count                *int       `json:"count,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+	Occurrences          []*Event   `json:"occurrences,omitempty"`
+}
+
+// SeriesUpdate is the outcome of a series edit. Occurrences that hold bookings or seat holds
+// are left as they are and listed in Skipped; they can still be changed one by one through the event API.
+type SeriesUpdate struct {
+	Series  *EventSeries `json:"series"`
+	Updated []*Event     `json:"updated"`
+	Skipped []uuid.UUID  `json:"skipped"`
+}
+
+// Venue is a place events happen at. Its timezone is an IANA name such as Europe/Moscow.
+type Venue struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	Timezone  string    `json:"timezone"`
+	Capacity  int       `json:"capacity"` // default total seats of events at the venue
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CapacityPool is a set of physical seats several events or ticket types sell from,
+// such as a hall shared by two sessions. A booking takes seats of the pool as well as of its event.
+type CapacityPool struct {
+	ID            uuid.UUID   `json:"id"`
+	Name          string      `json:"name"`
+	Capacity      int         `json:"capacity"`
+	Available     int         `json:"available"`
+	EventIDs      []uuid.UUID `json:"event_ids"`
+	TicketTypeIDs []uuid.UUID `json:"ticket_type_ids"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// SeatMap is the layout of a venue or of a single event. An event uses its own map
+// and falls back to the map of its venue.
+type SeatMap struct {
+	ID        uuid.UUID  `json:"id"`
+	VenueID   *uuid.UUID `json:"venue_id,omitempty"`
+	EventID   *uuid.UUID `json:"event_id,omitempty"`
+	Name      string     `json:"name"`
+	Seats     []Seat     `json:"seats"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type Seat struct {
+	ID         uuid.UUID `json:"id"`
+	Section    string    `json:"section"`
+	Row        string    `json:"row"`
+	Number     int       `json:"number"`
+	Wheelchair bool      `json:"wheelchair,omitempty"` // space for a wheelchair
+	Companion  bool      `json:"companion,omitempty"`  // next to a wheelchair space, for a companion
+}
+
+// EventSeat is a seat of the event's seat map together with whether it can still be booked.
+type EventSeat struct {
+	Seat
+	Available bool `json:"available"`
 }
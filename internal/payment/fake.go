@@ -0,0 +1,220 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeProviderName is stored with the payments made through the fake provider.
+const FakeProviderName = "fake"
+
+// FakeCheckoutPath is where the fake provider serves its checkout pages.
+const FakeCheckoutPath = "/payments/fake/checkout/"
+
+type fakeIntent struct {
+	request dto.PaymentIntentRequest
+	status  string
+}
+
+// Fake is a payment provider that runs inside the service, so the whole payment flow
+// can be tried without a real provider. Its checkout page lets the attendee pay or decline,
+// and the outcome is reported to the webhook with a signed request, like a real provider does.
+type Fake struct {
+	secret     []byte
+	publicURL  string
+	webhookURL string
+	client     *http.Client
+	mux        *http.ServeMux
+
+	mu      sync.Mutex
+	intents map[string]*fakeIntent
+	refunds map[string]string // idempotency key -> refund id
+}
+
+func NewFake(cfg *config.Config) *Fake {
+	publicURL := strings.TrimRight(cfg.Payment.PublicURL, "/")
+	webhookURL := cfg.Payment.WebhookURL
+	if webhookURL == "" {
+		webhookURL = publicURL + "/api/payments/webhook"
+	}
+
+	f := &Fake{
+		secret:     []byte(cfg.Payment.WebhookSecret),
+		publicURL:  publicURL,
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		mux:        http.NewServeMux(),
+		intents:    make(map[string]*fakeIntent),
+		refunds:    make(map[string]string),
+	}
+	f.mux.HandleFunc("GET "+FakeCheckoutPath+"{id}", f.checkoutPage)
+	f.mux.HandleFunc("POST "+FakeCheckoutPath+"{id}", f.submitCheckout)
+
+	return f
+}
+
+func (f *Fake) Name() string {
+	return FakeProviderName
+}
+
+func (f *Fake) CreatePaymentIntent(_ context.Context, req dto.PaymentIntentRequest) (*dto.PaymentIntent, error) {
+	id := "fake_" + uuid.NewString()
+
+	f.mu.Lock()
+	f.intents[id] = &fakeIntent{request: req, status: "pending"}
+	f.mu.Unlock()
+
+	return &dto.PaymentIntent{
+		ProviderPaymentID: id,
+		CheckoutURL:       f.publicURL + FakeCheckoutPath + id,
+	}, nil
+}
+
+func (f *Fake) ParseWebhook(payload []byte, signature string) (*dto.PaymentWebhook, error) {
+	if err := Verify(f.secret, payload, signature); err != nil {
+		return nil, err
+	}
+
+	var webhook dto.PaymentWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+// Refund always succeeds: the fake provider keeps its payments only in memory, so after a restart
+// it could not check them anyway. A repeated idempotency key returns the refund made the first time.
+func (f *Fake) Refund(_ context.Context, req dto.RefundRequest) (*dto.RefundResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, ok := f.refunds[req.IdempotencyKey]
+	if !ok {
+		id = "fake_refund_" + uuid.NewString()
+		f.refunds[req.IdempotencyKey] = id
+		zlog.Logger.Info().Str("paymentID", req.ProviderPaymentID).Int64("amountMinor", req.AmountMinor).
+			Str("currency", req.Currency).Msg("fake provider refunded payment")
+	}
+
+	return &dto.RefundResult{ProviderRefundID: id}, nil
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mux.ServeHTTP(w, r)
+}
+
+var checkoutTemplate = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="UTF-8"><title>Fake checkout</title><link rel="stylesheet" href="/web/styles.css"></head>
+<body>
+<div class="container">
+  <h1>Тестовая оплата</h1>
+  <p>{{.Description}}</p>
+  <p>Сумма: <b>{{.Amount}} {{.Currency}}</b></p>
+  {{if eq .Status "pending"}}
+  <form method="post">
+    <button class="btn btn-primary" name="outcome" value="succeeded">Оплатить</button>
+    <button class="btn btn-secondary" name="outcome" value="failed">Отказаться</button>
+  </form>
+  {{else}}
+  <p>Платёж {{.Status}}.</p>
+  {{end}}
+</div>
+</body>
+</html>`))
+
+func (f *Fake) checkoutPage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	f.mu.Lock()
+	intent, ok := f.intents[id]
+	var status string
+	if ok {
+		status = intent.status
+	}
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := struct {
+		Description string
+		Amount      string
+		Currency    string
+		Status      string
+	}{
+		Description: intent.request.Description,
+		Amount:      fmt.Sprintf("%d.%02d", intent.request.AmountMinor/100, intent.request.AmountMinor%100),
+		Currency:    intent.request.Currency,
+		Status:      status,
+	}
+	if err := checkoutTemplate.Execute(w, data); err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to render fake checkout")
+	}
+}
+
+// submitCheckout reports the outcome chosen on the checkout page to the webhook.
+func (f *Fake) submitCheckout(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	outcome := r.FormValue("outcome")
+	if outcome != "succeeded" && outcome != "failed" {
+		http.Error(w, "outcome must be succeeded or failed", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	intent, ok := f.intents[id]
+	if ok && intent.status == "pending" {
+		intent.status = outcome
+	}
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := f.sendWebhook(r.Context(), dto.PaymentWebhook{ProviderPaymentID: id, Status: outcome}); err != nil {
+		zlog.Logger.Error().Err(err).Str("paymentID", id).Msg("fake provider failed to deliver webhook")
+		http.Error(w, "failed to deliver webhook: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, FakeCheckoutPath+id, http.StatusSeeOther)
+}
+
+func (f *Fake) sendWebhook(ctx context.Context, webhook dto.PaymentWebhook) error {
+	payload, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(f.secret, payload))
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
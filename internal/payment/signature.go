@@ -0,0 +1,35 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the webhook body.
+const SignatureHeader = "X-Signature"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the hex encoded HMAC-SHA256 of payload with secret.
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that signature is the HMAC-SHA256 of payload with secret, in constant time.
+func Verify(secret, payload []byte, signature string) error {
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(secret) == 0 {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -41,14 +41,15 @@ func New(cfg *config.Config) *RabbitMq {
 	}
 }
 
-func (r *RabbitMq) Publish(booking dto.QueueMessage) error {
+// Publish sends the message through the delayed exchange; it is delivered to consumers after delay.
+func (r *RabbitMq) Publish(booking dto.QueueMessage, delay time.Duration) error {
 	if r.publisher == nil {
 		return fmt.Errorf("rabbitmq publisher is not initialized")
 	}
 
 	body, err := json.Marshal(booking)
 	if err != nil {
-		return fmt.Errorf("could not marshal booking to send to rabbitmq:" + err.Error())
+		return fmt.Errorf("could not marshal booking to send to rabbitmq: %w", err)
 	}
 
 	strategy := retry.Strategy{
@@ -57,8 +58,12 @@ func (r *RabbitMq) Publish(booking dto.QueueMessage) error {
 		Backoff:  2,
 	}
 
+	if delay < 0 {
+		delay = 0
+	}
+
 	headers := amqp.Table{
-		"x-delay": (time.Minute * 15).Milliseconds(), // отправляет после 15 минут (период ожидания оплаты)
+		"x-delay": delay.Milliseconds(), // отправляет после окончания периода ожидания оплаты
 	}
 
 	options := rabbitmq.PublishingOptions{
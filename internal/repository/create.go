@@ -2,81 +2,299 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/K1la/event-booker/internal/config"
 	"github.com/K1la/event-booker/internal/dto"
 	"github.com/K1la/event-booker/internal/model"
-	"github.com/lib/pq"
+	"github.com/google/uuid"
+	"strings"
+	"time"
 )
 
 func (r *Postgres) CreateEvent(ctx context.Context, event *dto.CreateEvent) (*model.Event, error) {
+	if err := normalizeEvent(event); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created, err := insertEvent(ctx, tx, event, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// normalizeEvent fills in the defaults of a new event and validates it.
+func normalizeEvent(event *dto.CreateEvent) error {
+	event.Title = strings.TrimSpace(event.Title)
+	if event.Title == "" {
+		return ErrEmptyEventTitle
+	}
+	if event.TotalSeats <= 0 {
+		return ErrInvalidTotalSeats
+	}
+
+	switch event.Status {
+	case "":
+		event.Status = EventDraft
+	case EventDraft, EventPublished:
+	default:
+		return ErrInvalidEventStatus
+	}
+
+	if event.PaymentWindowMinutes == 0 {
+		event.PaymentWindowMinutes = DefaultPaymentWindowMinutes
+	}
+	if event.PaymentWindowMinutes < 0 {
+		return ErrInvalidPaymentWindow
+	}
+
+	// without explicit times the event is on sale from now until it starts
+	if event.SaleOpensAt == nil {
+		now := time.Now()
+		event.SaleOpensAt = &now
+	}
+	if event.SaleClosesAt == nil {
+		event.SaleClosesAt = &event.EventAt
+	}
+	if !validSalesWindow(*event.SaleOpensAt, *event.SaleClosesAt, event.EventAt) {
+		return ErrInvalidSalesWindow
+	}
+	return nil
+}
+
+// rowQuerier is what insertEvent needs from either the database or a transaction.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertEvent stores a normalized event, as an occurrence at position of the series if seriesID is set.
+func insertEvent(ctx context.Context, q rowQuerier, event *dto.CreateEvent, seriesID *uuid.UUID, position int) (*model.Event, error) {
 	query := `
-	INSERT INTO events(title, event_at, total_seats, available_seats)
-	VALUES ($1, $2, $3, $4) RETURNING id, created_at
-	`
+	INSERT INTO events(title, event_at, total_seats, available_seats, payment_window_minutes, status, sale_opens_at, sale_closes_at,
+		series_id, series_position, venue_id, pool_id)
+	VALUES ($1, $2, $3, $3, $4, $5, $6, $7, $8, NULLIF($9, 0), $10, $11) RETURNING ` + eventColumns
 
 	var createdEvent model.Event
-	err := r.db.QueryRowContext(ctx, query, event.Title, event.EventAt, event.TotalSeats, event.TotalSeats).Scan(
-		&createdEvent.ID, &createdEvent.CreatedAt)
+	err := q.QueryRowContext(ctx, query, event.Title, event.EventAt, event.TotalSeats, event.PaymentWindowMinutes, event.Status,
+		*event.SaleOpensAt, *event.SaleClosesAt, seriesID, position, event.VenueID, event.PoolID).Scan(eventFields(&createdEvent)...)
 	if err != nil {
+		if isForeignKeyViolation(err, "events_venue_id_fkey") {
+			return nil, ErrNoSuchVenue
+		}
+		if isForeignKeyViolation(err, "events_pool_id_fkey") {
+			return nil, ErrNoSuchPool
+		}
 		return nil, fmt.Errorf("failed to create event in db: %w", err)
 	}
 
-	createdEvent.EventAt = event.EventAt
-	createdEvent.Title = event.Title
-	createdEvent.TotalSeats = event.TotalSeats
-	createdEvent.AvailableSeats = event.TotalSeats
+	// the venue may have a seat map bigger than an explicit total_seats
+	if createdEvent.VenueID != nil {
+		if err = checkEventSeatMap(ctx, q, createdEvent.ID, nil); err != nil {
+			return nil, err
+		}
+	}
 
 	return &createdEvent, nil
 }
 
-func (r *Postgres) CreateBooking(ctx context.Context, booking *dto.CreateBooking) (*model.Booking, error) {
+// CreateBooking reserves the seats and creates a pending booking, if it stays within limits.
+func (r *Postgres) CreateBooking(ctx context.Context, booking *dto.CreateBooking, limits config.Limits) (*model.Booking, error) {
+	if booking.PlacesCount <= 0 {
+		return nil, ErrInvalidPlacesCount
+	}
+	seatIDs, err := selectedSeats(booking.PlacesCount, booking.SeatIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := r.db.Master.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	var createdBooking model.Booking
-	bookingsQuery := `INSERT INTO bookings(event_id, status, telegram_id)
-	VALUES ($1, $2, $3) RETURNING id, created_at`
+	if err = checkBookingLimits(ctx, tx, limits, booking.TelegramID, booking.EventID, booking.PlacesCount); err != nil {
+		return nil, err
+	}
+
+	if err = checkSeatSelection(ctx, tx, booking.EventID, seatIDs); err != nil {
+		return nil, err
+	}
+
+	if err = reserveSeats(ctx, tx, booking.EventID, booking.TicketTypeID, booking.PlacesCount); err != nil {
+		return nil, err
+	}
 
-	err = tx.QueryRowContext(ctx, bookingsQuery, booking.EventID, statusPending, booking.TelegramID).Scan(
-		&createdBooking.ID, &createdBooking.CreatedAt)
+	createdBooking, err := insertBooking(ctx, tx, booking.EventID, booking.TicketTypeID, booking.TelegramID, booking.PlacesCount,
+		dto.StatusChange{Actor: ActorUser, Reason: ReasonBookingCreated})
 	if err != nil {
-		var pgErr *pq.Error
-		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
-			return nil, ErrNoSuchEvent
+		return nil, err
+	}
+
+	if booking.PromoCode != "" {
+		if createdBooking, err = applyPromoCode(ctx, tx, createdBooking, booking.PromoCode); err != nil {
+			return nil, err
 		}
+	}
 
+	if len(seatIDs) > 0 {
+		createdBooking.SeatIDs, err = allocateSeats(ctx, tx, booking.EventID, seatOwner{bookingID: &createdBooking.ID}, seatIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return createdBooking, nil
+}
+
+// insertBooking creates a pending booking inside tx together with its first history entry
+// and its expiry message in the outbox. The seats must already be reserved.
+// The payment deadline is derived from the event's payment window and the charged price
+// from the ticket type, if any.
+func insertBooking(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID, telegramID, places int, change dto.StatusChange) (*model.Booking, error) {
+	bookingsQuery := `INSERT INTO bookings(event_id, status, telegram_id, places_count, expires_at, ticket_type_id, price_minor, currency)
+	SELECT e.id, $2, $3, $4, NOW() + make_interval(mins => e.payment_window_minutes),
+	       tt.id, COALESCE(tt.price_minor, 0) * $4, tt.currency
+	FROM events e
+	LEFT JOIN ticket_types tt ON tt.id = $5 AND tt.event_id = e.id
+	WHERE e.id = $1
+	RETURNING ` + bookingColumns
+
+	createdBooking, err := scanBooking(tx.QueryRowContext(ctx, bookingsQuery, eventID, StatusPending, telegramID, places, ticketTypeID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchEvent
+		}
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
 
-	EventsQuery := `UPDATE events
-	SET available_seats = available_seats - $1
-	WHERE id = $2 AND available_seats >= 0`
-	result, err := tx.ExecContext(ctx, EventsQuery, booking.PlacesCount, booking.EventID) // booking.PlacesCount,
+	if err = recordHistory(ctx, tx, createdBooking.ID, "", StatusPending, change); err != nil {
+		return nil, err
+	}
+
+	if err = enqueueExpiry(ctx, tx, createdBooking.ID, createdBooking.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return createdBooking, nil
+}
+
+// reserveSeats takes places seats of the event, and of the ticket type if one is given, inside tx.
+// The decrement and the availability check are a single statement, so concurrent
+// reservations serialize on the event row and can never push the counter below zero.
+func reserveSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID, places int) error {
+	query := `UPDATE events
+	SET available_seats = available_seats - $1,
+	    updated_at = NOW()
+	WHERE id = $2 AND status = $3 AND available_seats >= $1
+	  AND sale_opens_at <= NOW() AND sale_closes_at > NOW()`
+
+	result, err := tx.ExecContext(ctx, query, places, eventID, EventPublished)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking in event: %w", err)
+		return fmt.Errorf("failed to reserve seats: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, fmt.Errorf("failed to update booking in event: %w", err)
+		return fmt.Errorf("failed to reserve seats: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return nil, ErrNoSeatsAvailable
+		if err = eventSaleError(ctx, tx, eventID); err != nil {
+			return err
+		}
+		return ErrNoSeatsAvailable
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if err = reserveTicketTypeSeats(ctx, tx, eventID, ticketTypeID, places); err != nil {
+		return err
 	}
 
-	createdBooking.EventID = booking.EventID
-	createdBooking.TelegramID = booking.TelegramID
-	createdBooking.PlacesCount = booking.PlacesCount
-	createdBooking.Status = statusPending
+	return takePoolSeats(ctx, tx, eventID, ticketTypeID, places)
+}
+
+// eventSaleError tells why the event does not sell right now, or returns nil if it does.
+func eventSaleError(ctx context.Context, q rowQuerier, eventID uuid.UUID) error {
+	var status string
+	var notOpen, closed bool
+	err := q.QueryRowContext(ctx, `SELECT status, sale_opens_at > NOW(), sale_closes_at <= NOW()
+	FROM events WHERE id = $1`, eventID).Scan(&status, &notOpen, &closed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoSuchEvent
+		}
+		return fmt.Errorf("failed to check event: %w", err)
+	}
+	switch {
+	case status != EventPublished:
+		return eventStateError(status)
+	case notOpen:
+		return ErrSalesNotOpen
+	case closed:
+		return ErrSalesClosed
+	}
+	return nil
+}
+
+// reserveTicketTypeSeats takes places seats of the ticket type inside tx. The event row is already
+// locked by reserveSeats, so the ticket types of the event can not change meanwhile.
+func reserveTicketTypeSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID, places int) error {
+	if ticketTypeID == nil {
+		var hasTypes bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM ticket_types WHERE event_id = $1)`, eventID).Scan(&hasTypes)
+		if err != nil {
+			return fmt.Errorf("failed to check ticket types: %w", err)
+		}
+		if hasTypes {
+			return ErrTicketTypeRequired
+		}
+		return nil
+	}
+
+	query := `UPDATE ticket_types
+	SET available = available - $1,
+	    updated_at = NOW()
+	WHERE id = $2 AND event_id = $3 AND available >= $1`
+
+	result, err := tx.ExecContext(ctx, query, places, *ticketTypeID, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to reserve ticket type seats: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to reserve ticket type seats: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM ticket_types WHERE id = $1 AND event_id = $2)`,
+			*ticketTypeID, eventID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check ticket type: %w", err)
+		}
+		if !exists {
+			return ErrNoSuchTicketType
+		}
+		return ErrNoSeatsAvailable
+	}
 
-	return &createdBooking, nil
+	return nil
 }
@@ -0,0 +1,794 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+// The concurrency tests need a migrated PostgreSQL database, e.g.
+// TEST_DB_DSN="host=localhost port=5432 user=postgres password=postgres dbname=event-booker sslmode=disable"
+const testDSNEnv = "TEST_DB_DSN"
+
+// noLimits turns the booking limits off for tests that are about something else.
+var noLimits config.Limits
+
+func newTestRepo(t *testing.T) *Postgres {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set, skipping database test", testDSNEnv)
+	}
+
+	db, err := dbpg.New(dsn, []string{}, &dbpg.Options{MaxOpenConns: 50, MaxIdleConns: 50})
+	if err != nil {
+		t.Fatalf("could not connect to db: %v", err)
+	}
+	t.Cleanup(func() { db.Master.Close() })
+
+	return New(db)
+}
+
+func newTestEvent(t *testing.T, r *Postgres, seats int) uuid.UUID {
+	t.Helper()
+
+	ctx := context.Background()
+	event, err := r.CreateEvent(ctx, &dto.CreateEvent{
+		Title:      "concurrency test " + t.Name(),
+		EventAt:    time.Now().Add(24 * time.Hour),
+		TotalSeats: seats,
+		Status:     EventPublished,
+	})
+	if err != nil {
+		t.Fatalf("could not create event: %v", err)
+	}
+
+	t.Cleanup(func() {
+		tx, err := r.db.Master.BeginTx(ctx, nil)
+		if err != nil {
+			t.Errorf("cleanup failed: %v", err)
+			return
+		}
+		defer tx.Rollback()
+
+		// booking history is append-only; only the test's own rows are removed, with triggers off
+		if _, err = tx.ExecContext(ctx, `SET LOCAL session_replication_role = replica`); err != nil {
+			t.Errorf("cleanup failed: %v", err)
+			return
+		}
+
+		for _, query := range []string{
+			`DELETE FROM notifications WHERE event_id = $1`,
+			`DELETE FROM event_reschedules WHERE event_id = $1`,
+			`DELETE FROM seat_holds WHERE event_id = $1`,
+			`DELETE FROM seat_allocations WHERE event_id = $1`,
+			`DELETE FROM outbox WHERE booking_id IN (SELECT id FROM bookings WHERE event_id = $1)`,
+			`DELETE FROM promo_redemptions WHERE booking_id IN (SELECT id FROM bookings WHERE event_id = $1)`,
+			`DELETE FROM refunds WHERE booking_id IN (SELECT id FROM bookings WHERE event_id = $1)`,
+			`DELETE FROM payments WHERE booking_id IN (SELECT id FROM bookings WHERE event_id = $1)`,
+			`DELETE FROM booking_history WHERE booking_id IN (SELECT id FROM bookings WHERE event_id = $1)`,
+			`DELETE FROM waitlist WHERE event_id = $1`,
+			`DELETE FROM bookings WHERE event_id = $1`,
+			`DELETE FROM ticket_types WHERE event_id = $1`,
+			`DELETE FROM seats WHERE seat_map_id IN (SELECT id FROM seat_maps WHERE event_id = $1)`,
+			`DELETE FROM seat_maps WHERE event_id = $1`,
+			`DELETE FROM events WHERE id = $1`,
+		} {
+			if _, err = tx.ExecContext(ctx, query, event.ID); err != nil {
+				t.Errorf("cleanup failed: %v", err)
+				return
+			}
+		}
+
+		if err = tx.Commit(); err != nil {
+			t.Errorf("cleanup failed: %v", err)
+		}
+	})
+
+	return event.ID
+}
+
+// assertSeatsConsistent checks that the event counter matches the seats held by active bookings and seat holds.
+func assertSeatsConsistent(t *testing.T, r *Postgres, eventID uuid.UUID) int {
+	t.Helper()
+
+	var total, available, held int
+	err := r.db.Master.QueryRow(`
+		SELECT e.total_seats, e.available_seats,
+		       (SELECT COALESCE(SUM(places_count), 0) FROM bookings
+		        WHERE event_id = e.id AND status IN ('pending', 'confirmed')) +
+		       (SELECT COALESCE(SUM(places_count), 0) FROM seat_holds
+		        WHERE event_id = e.id AND status = 'active')
+		FROM events e
+		WHERE e.id = $1`, eventID).Scan(&total, &available, &held)
+	if err != nil {
+		t.Fatalf("could not read event seats: %v", err)
+	}
+
+	if available < 0 {
+		t.Fatalf("event %s is oversold: available_seats = %d", eventID, available)
+	}
+	if held > total {
+		t.Fatalf("event %s is oversold: %d seats held of %d", eventID, held, total)
+	}
+	if available != total-held {
+		t.Fatalf("event %s counter drifted: available_seats = %d, want %d", eventID, available, total-held)
+	}
+
+	return available
+}
+
+func TestCreateBookingNoOversell(t *testing.T) {
+	r := newTestRepo(t)
+	const seats, attempts = 100, 400
+	eventID := newTestEvent(t, r, seats)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		booked  int
+		maxFail int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			places := i%3 + 1
+			_, err := r.CreateBooking(context.Background(), &dto.CreateBooking{
+				EventID:     eventID,
+				TelegramID:  i + 1,
+				PlacesCount: places,
+			}, noLimits)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				booked += places
+			case errors.Is(err, ErrNoSeatsAvailable):
+				maxFail = max(maxFail, places)
+			default:
+				t.Errorf("unexpected booking error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	available := assertSeatsConsistent(t, r, eventID)
+	if booked != seats-available {
+		t.Fatalf("booked %d seats, but the event lost %d", booked, seats-available)
+	}
+	// seats only ever go down here, so a rejected request means fewer seats than it asked for were left
+	if maxFail > 0 && available >= maxFail {
+		t.Fatalf("a request for %d seats was rejected while %d are still available", maxFail, available)
+	}
+}
+
+func TestCreateBookingNoOversellAcrossEvents(t *testing.T) {
+	r := newTestRepo(t)
+	const events, seats, attemptsPerEvent = 5, 20, 60
+
+	eventIDs := make([]uuid.UUID, events)
+	for i := range eventIDs {
+		eventIDs[i] = newTestEvent(t, r, seats)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < events*attemptsPerEvent; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := r.CreateBooking(context.Background(), &dto.CreateBooking{
+				EventID:     eventIDs[i%events],
+				TelegramID:  i + 1,
+				PlacesCount: i%2 + 1,
+			}, noLimits)
+			if err != nil && !errors.Is(err, ErrNoSeatsAvailable) {
+				t.Errorf("unexpected booking error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, eventID := range eventIDs {
+		assertSeatsConsistent(t, r, eventID)
+	}
+}
+
+func TestCreateBookingConcurrentWithRelease(t *testing.T) {
+	r := newTestRepo(t)
+	const seats, attempts = 30, 300
+	eventID := newTestEvent(t, r, seats)
+
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := context.Background()
+
+			booking, err := r.CreateBooking(ctx, &dto.CreateBooking{
+				EventID:     eventID,
+				TelegramID:  i + 1,
+				PlacesCount: i%4 + 1,
+			}, noLimits)
+			if err != nil {
+				if !errors.Is(err, ErrNoSeatsAvailable) {
+					t.Errorf("unexpected booking error: %v", err)
+				}
+				return
+			}
+
+			// half of the bookings are released again, racing the user cancel against expiry
+			if i%2 == 0 {
+				var cancelErr, expireErr error
+				var release sync.WaitGroup
+				release.Add(2)
+				change := dto.StatusChange{Actor: ActorUser, Reason: ReasonCancelledByUser}
+				go func() { defer release.Done(); _, cancelErr = r.CancelBooking(ctx, booking.ID, change, 0) }()
+				go func() { defer release.Done(); _, expireErr = r.ExpireBooking(ctx, booking.ID) }()
+				release.Wait()
+
+				if (cancelErr == nil) == (expireErr == nil) {
+					t.Errorf("booking %s: want exactly one release to win, got cancel=%v expire=%v",
+						booking.ID, cancelErr, expireErr)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assertSeatsConsistent(t, r, eventID)
+}
+
+func TestCancelEventConcurrentWithRelease(t *testing.T) {
+	r := newTestRepo(t)
+	const seats = 40
+	eventID := newTestEvent(t, r, seats)
+	ctx := context.Background()
+
+	var bookingIDs []uuid.UUID
+	for i := 0; i < seats; i++ {
+		booking, err := r.CreateBooking(ctx, &dto.CreateBooking{
+			EventID:     eventID,
+			TelegramID:  i + 1,
+			PlacesCount: 1,
+		}, noLimits)
+		if err != nil {
+			t.Fatalf("could not book: %v", err)
+		}
+		bookingIDs = append(bookingIDs, booking.ID)
+	}
+
+	// the bookings are cancelled and expired while the whole event is cancelled;
+	// none of them may deadlock with the event cancellation, which takes the same locks
+	var wg sync.WaitGroup
+	for i, bookingID := range bookingIDs {
+		wg.Add(1)
+		go func(i int, bookingID uuid.UUID) {
+			defer wg.Done()
+
+			var err error
+			if i%2 == 0 {
+				change := dto.StatusChange{Actor: ActorUser, Reason: ReasonCancelledByUser}
+				_, err = r.CancelBooking(ctx, bookingID, change, 0)
+			} else {
+				_, err = r.ExpireBooking(ctx, bookingID)
+			}
+			if err != nil && !errors.Is(err, ErrBookingNotFoundOrAlreadyCancelled) {
+				t.Errorf("booking %s: unexpected release error: %v", bookingID, err)
+			}
+		}(i, bookingID)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := r.CancelEvent(ctx, eventID, "cancelled", "cancelled"); err != nil {
+			t.Errorf("unexpected event cancel error: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	var active int
+	err := r.db.Master.QueryRow(`SELECT COUNT(*) FROM bookings
+		WHERE event_id = $1 AND status IN ('pending', 'confirmed')`, eventID).Scan(&active)
+	if err != nil {
+		t.Fatalf("could not count bookings: %v", err)
+	}
+	if active != 0 {
+		t.Fatalf("%d bookings are still active after the event was cancelled", active)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != seats {
+		t.Fatalf("available_seats = %d after the event was cancelled, want %d", available, seats)
+	}
+}
+
+func TestCreateBookingRejectsNonPositivePlaces(t *testing.T) {
+	r := newTestRepo(t)
+	eventID := newTestEvent(t, r, 10)
+
+	for _, places := range []int{0, -1, -100} {
+		_, err := r.CreateBooking(context.Background(), &dto.CreateBooking{
+			EventID:     eventID,
+			TelegramID:  1,
+			PlacesCount: places,
+		}, noLimits)
+		if !errors.Is(err, ErrInvalidPlacesCount) {
+			t.Fatalf("places_count %d: got %v, want %v", places, err, ErrInvalidPlacesCount)
+		}
+	}
+
+	if available := assertSeatsConsistent(t, r, eventID); available != 10 {
+		t.Fatalf("available_seats = %d, want 10", available)
+	}
+}
+
+func TestNormalizeEventRejectsInvalid(t *testing.T) {
+	eventAt := time.Now().Add(24 * time.Hour)
+	tests := []struct {
+		name  string
+		event dto.CreateEvent
+		want  error
+	}{
+		{name: "empty title", event: dto.CreateEvent{Title: "  ", EventAt: eventAt, TotalSeats: 10}, want: ErrEmptyEventTitle},
+		{name: "no seats", event: dto.CreateEvent{Title: "concert", EventAt: eventAt}, want: ErrInvalidTotalSeats},
+		{name: "negative seats", event: dto.CreateEvent{Title: "concert", EventAt: eventAt, TotalSeats: -5}, want: ErrInvalidTotalSeats},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := normalizeEvent(&tt.event); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCreateBookingTicketTypesRollUp(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	eventID := newTestEvent(t, r, 10)
+
+	vip, err := r.CreateTicketType(ctx, eventID, &dto.CreateTicketType{Name: "VIP", PriceMinor: 5000, Currency: "rub", Capacity: 4})
+	if err != nil {
+		t.Fatalf("could not create ticket type: %v", err)
+	}
+	if _, err = r.CreateTicketType(ctx, eventID, &dto.CreateTicketType{Name: "Standard", PriceMinor: 1000, Currency: "RUB", Capacity: 7}); !errors.Is(err, ErrTicketTypeCapacityExceeded) {
+		t.Fatalf("capacities over the event seats: got %v, want %v", err, ErrTicketTypeCapacityExceeded)
+	}
+
+	if _, err = r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 1, PlacesCount: 1}, noLimits); !errors.Is(err, ErrTicketTypeRequired) {
+		t.Fatalf("booking without a type: got %v, want %v", err, ErrTicketTypeRequired)
+	}
+
+	booking, err := r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 1, PlacesCount: 3, TicketTypeID: &vip.ID}, noLimits)
+	if err != nil {
+		t.Fatalf("could not book: %v", err)
+	}
+	if booking.PriceMinor != 15000 || booking.Currency != "RUB" {
+		t.Fatalf("booking charged %d %s, want 15000 RUB", booking.PriceMinor, booking.Currency)
+	}
+
+	if _, err = r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 2, PlacesCount: 2, TicketTypeID: &vip.ID}, noLimits); !errors.Is(err, ErrNoSeatsAvailable) {
+		t.Fatalf("booking over the type capacity: got %v, want %v", err, ErrNoSeatsAvailable)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 7 {
+		t.Fatalf("available_seats = %d, want 7", available)
+	}
+
+	if _, err = r.CancelBooking(ctx, booking.ID, dto.StatusChange{Actor: ActorUser, Reason: ReasonCancelledByUser}, 0); err != nil {
+		t.Fatalf("could not cancel booking: %v", err)
+	}
+	types, err := r.GetTicketTypes(ctx, eventID)
+	if err != nil {
+		t.Fatalf("could not get ticket types: %v", err)
+	}
+	if len(types) != 1 || types[0].Available != 4 {
+		t.Fatalf("ticket types after cancel = %+v, want VIP with 4 available", types)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 10 {
+		t.Fatalf("available_seats = %d, want 10", available)
+	}
+}
+
+func TestCreateBookingPromoCodeLimit(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	const maxUses, attempts = 5, 50
+
+	// registered before the event so that it runs after the event's bookings are gone
+	var promoID uuid.UUID
+	t.Cleanup(func() {
+		if _, err := r.db.Master.ExecContext(ctx, `DELETE FROM promo_codes WHERE id = $1`, promoID); err != nil {
+			t.Errorf("cleanup failed: %v", err)
+		}
+	})
+	eventID := newTestEvent(t, r, attempts)
+
+	ticketType, err := r.CreateTicketType(ctx, eventID, &dto.CreateTicketType{Name: "Standard", PriceMinor: 1000, Currency: "RUB", Capacity: attempts})
+	if err != nil {
+		t.Fatalf("could not create ticket type: %v", err)
+	}
+	limit := maxUses
+	promo, err := r.CreatePromoCode(ctx, &dto.CreatePromoCode{
+		Code:          "limit-" + eventID.String(),
+		DiscountType:  PromoPercent,
+		DiscountValue: 20,
+		MaxUses:       &limit,
+		EventIDs:      []uuid.UUID{eventID},
+	})
+	if err != nil {
+		t.Fatalf("could not create promo code: %v", err)
+	}
+	promoID = promo.ID
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		discounted []uuid.UUID
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			booking, err := r.CreateBooking(ctx, &dto.CreateBooking{
+				EventID:      eventID,
+				TelegramID:   i + 1,
+				PlacesCount:  1,
+				TicketTypeID: &ticketType.ID,
+				PromoCode:    promo.Code,
+			}, noLimits)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				if booking.PriceMinor != 800 || booking.DiscountMinor != 200 {
+					t.Errorf("discounted booking charged %d with discount %d, want 800 and 200", booking.PriceMinor, booking.DiscountMinor)
+				}
+				discounted = append(discounted, booking.ID)
+			case errors.Is(err, ErrPromoCodeExhausted):
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(discounted) != maxUses {
+		t.Fatalf("%d bookings used the code, want %d", len(discounted), maxUses)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != attempts-maxUses {
+		t.Fatalf("available_seats = %d, want %d", available, attempts-maxUses)
+	}
+
+	// an unpaid booking that ends gives its use back
+	if _, err = r.CancelBooking(ctx, discounted[0], dto.StatusChange{Actor: ActorUser, Reason: ReasonCancelledByUser}, 0); err != nil {
+		t.Fatalf("could not cancel booking: %v", err)
+	}
+	report, err := r.GetPromoCodeReport(ctx, promo.ID)
+	if err != nil {
+		t.Fatalf("could not get promo code report: %v", err)
+	}
+	if report.PromoCode.UsedCount != maxUses-1 || report.Applied != maxUses-1 || report.Released != 1 {
+		t.Fatalf("report = %+v, want %d uses, %d applied and 1 released", report, maxUses-1, maxUses-1)
+	}
+}
+
+func TestSeatHoldConvertAndExpire(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	eventID := newTestEvent(t, r, 10)
+
+	hold, err := r.CreateHold(ctx, &dto.CreateHold{EventID: eventID, TelegramID: 1, PlacesCount: 3}, time.Minute, noLimits)
+	if err != nil {
+		t.Fatalf("could not hold seats: %v", err)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 7 {
+		t.Fatalf("available_seats with a hold = %d, want 7", available)
+	}
+
+	booking, err := r.ConvertHold(ctx, hold.ID, &dto.ConvertHold{})
+	if err != nil {
+		t.Fatalf("could not convert hold: %v", err)
+	}
+	if booking.PlacesCount != 3 || booking.Status != StatusPending {
+		t.Fatalf("booking from hold = %+v, want 3 pending seats", booking)
+	}
+	if _, err = r.ConvertHold(ctx, hold.ID, &dto.ConvertHold{}); !errors.Is(err, ErrHoldNotActive) {
+		t.Fatalf("second conversion: got %v, want %v", err, ErrHoldNotActive)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 7 {
+		t.Fatalf("available_seats after conversion = %d, want 7", available)
+	}
+
+	lapsed, err := r.CreateHold(ctx, &dto.CreateHold{EventID: eventID, TelegramID: 2, PlacesCount: 2}, time.Millisecond, noLimits)
+	if err != nil {
+		t.Fatalf("could not hold seats: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err = r.ExpireHolds(ctx, 100); err != nil {
+		t.Fatalf("could not expire holds: %v", err)
+	}
+	if _, err = r.ConvertHold(ctx, lapsed.ID, &dto.ConvertHold{}); !errors.Is(err, ErrHoldExpired) {
+		t.Fatalf("conversion of a lapsed hold: got %v, want %v", err, ErrHoldExpired)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 7 {
+		t.Fatalf("available_seats after expiry = %d, want 7", available)
+	}
+}
+
+func TestCapacityPoolNoOversell(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	const poolSeats, attempts = 5, 40
+
+	pool, err := r.CreateCapacityPool(ctx, &dto.CreateCapacityPool{Name: "hall " + t.Name(), Capacity: poolSeats})
+	if err != nil {
+		t.Fatalf("could not create capacity pool: %v", err)
+	}
+	// registered before the events, so it runs after their cleanup
+	t.Cleanup(func() {
+		if _, err := r.db.Master.Exec(`DELETE FROM capacity_pools WHERE id = $1`, pool.ID); err != nil {
+			t.Errorf("cleanup failed: %v", err)
+		}
+	})
+
+	events := []uuid.UUID{newTestEvent(t, r, 10), newTestEvent(t, r, 10)}
+	for _, eventID := range events {
+		if _, err = r.db.Master.Exec(`UPDATE events SET pool_id = $1 WHERE id = $2`, pool.ID, eventID); err != nil {
+			t.Fatalf("could not link event to pool: %v", err)
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		bookings []uuid.UUID
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			booking, err := r.CreateBooking(ctx, &dto.CreateBooking{EventID: events[i%2], TelegramID: i + 1, PlacesCount: 1}, noLimits)
+			if err != nil {
+				if !errors.Is(err, ErrPoolExhausted) {
+					t.Errorf("unexpected booking error: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			bookings = append(bookings, booking.ID)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(bookings) != poolSeats {
+		t.Fatalf("booked %d seats from a pool of %d", len(bookings), poolSeats)
+	}
+	for _, eventID := range events {
+		assertSeatsConsistent(t, r, eventID)
+	}
+
+	if _, err = r.CancelBooking(ctx, bookings[0], dto.StatusChange{Actor: ActorUser, Reason: ReasonCancelledByUser}, 0); err != nil {
+		t.Fatalf("could not cancel booking: %v", err)
+	}
+	got, err := r.GetCapacityPoolByID(ctx, pool.ID)
+	if err != nil {
+		t.Fatalf("could not get capacity pool: %v", err)
+	}
+	if got.Available != 1 {
+		t.Fatalf("pool available after cancellation = %d, want 1", got.Available)
+	}
+}
+
+func TestSeatAllocationNoDoubleBooking(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	const attempts = 20
+	eventID := newTestEvent(t, r, 10)
+
+	seatMap, err := r.CreateSeatMap(ctx, &dto.CreateSeatMap{
+		EventID: &eventID,
+		Name:    "stalls",
+		Sections: []dto.SeatSection{{Name: "stalls", Rows: []dto.SeatRow{{Name: "A", Seats: []dto.SeatSpec{
+			{Number: 1, Wheelchair: true}, {Number: 2, Companion: true}, {Number: 3},
+		}}}}},
+	})
+	if err != nil {
+		t.Fatalf("could not create seat map: %v", err)
+	}
+	wanted := []uuid.UUID{seatMap.Seats[0].ID, seatMap.Seats[1].ID}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		bookings []uuid.UUID
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// half of the users list the seats the other way round
+			seats := slices.Clone(wanted)
+			if i%2 == 1 {
+				slices.Reverse(seats)
+			}
+			booking, err := r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: i + 1, PlacesCount: 2, SeatIDs: seats}, noLimits)
+			if err != nil {
+				if !errors.Is(err, ErrSeatTaken) {
+					t.Errorf("unexpected booking error: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			bookings = append(bookings, booking.ID)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(bookings) != 1 {
+		t.Fatalf("the same seats were booked %d times", len(bookings))
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 8 {
+		t.Fatalf("available_seats = %d, want 8", available)
+	}
+
+	if _, err = r.CancelBooking(ctx, bookings[0], dto.StatusChange{Actor: ActorUser, Reason: ReasonCancelledByUser}, 0); err != nil {
+		t.Fatalf("could not cancel booking: %v", err)
+	}
+	seats, err := r.GetEventSeats(ctx, eventID)
+	if err != nil {
+		t.Fatalf("could not get event seats: %v", err)
+	}
+	for _, seat := range seats {
+		if !seat.Available {
+			t.Fatalf("seat %s %d is still taken after the cancellation", seat.Row, seat.Number)
+		}
+	}
+	if _, err = r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 1, PlacesCount: 1, SeatIDs: wanted[:1]}, noLimits); err != nil {
+		t.Fatalf("could not book a released seat: %v", err)
+	}
+}
+
+func TestSeatMapRequiresSeats(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	eventID := newTestEvent(t, r, 2)
+	row := func(numbers ...int) *dto.CreateSeatMap {
+		var seats []dto.SeatSpec
+		for _, number := range numbers {
+			seats = append(seats, dto.SeatSpec{Number: number})
+		}
+		return &dto.CreateSeatMap{EventID: &eventID, Name: "stalls",
+			Sections: []dto.SeatSection{{Name: "stalls", Rows: []dto.SeatRow{{Name: "A", Seats: seats}}}}}
+	}
+
+	if _, err := r.CreateSeatMap(ctx, row(1, 2, 3)); !errors.Is(err, ErrSeatMapTooLarge) {
+		t.Fatalf("seat map larger than the event: got %v, want %v", err, ErrSeatMapTooLarge)
+	}
+	seatMap, err := r.CreateSeatMap(ctx, row(1, 2))
+	if err != nil {
+		t.Fatalf("could not create seat map: %v", err)
+	}
+
+	_, err = r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 1, PlacesCount: 1}, noLimits)
+	if !errors.Is(err, ErrSeatSelectionRequired) {
+		t.Fatalf("booking without seats: got %v, want %v", err, ErrSeatSelectionRequired)
+	}
+	_, err = r.CreateHold(ctx, &dto.CreateHold{EventID: eventID, TelegramID: 1, PlacesCount: 1}, time.Minute, noLimits)
+	if !errors.Is(err, ErrSeatSelectionRequired) {
+		t.Fatalf("hold without seats: got %v, want %v", err, ErrSeatSelectionRequired)
+	}
+
+	seatID := seatMap.Seats[0].ID
+	hold, err := r.CreateHold(ctx, &dto.CreateHold{EventID: eventID, TelegramID: 1, PlacesCount: 1, SeatIDs: []uuid.UUID{seatID}},
+		time.Minute, noLimits)
+	if err != nil {
+		t.Fatalf("could not hold a seat: %v", err)
+	}
+	_, err = r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 2, PlacesCount: 1, SeatIDs: []uuid.UUID{seatID}}, noLimits)
+	if !errors.Is(err, ErrSeatTaken) {
+		t.Fatalf("booking of a held seat: got %v, want %v", err, ErrSeatTaken)
+	}
+
+	booking, err := r.ConvertHold(ctx, hold.ID, &dto.ConvertHold{})
+	if err != nil {
+		t.Fatalf("could not convert hold: %v", err)
+	}
+	if !slices.Equal(booking.SeatIDs, []uuid.UUID{seatID}) {
+		t.Fatalf("booking from hold has seats %v, want %v", booking.SeatIDs, seatID)
+	}
+	seats, err := r.GetEventSeats(ctx, eventID)
+	if err != nil {
+		t.Fatalf("could not get event seats: %v", err)
+	}
+	if seats[0].Available || !seats[1].Available {
+		t.Fatalf("event seats = %+v, want only the booked seat taken", seats)
+	}
+	if available := assertSeatsConsistent(t, r, eventID); available != 1 {
+		t.Fatalf("available_seats = %d, want 1", available)
+	}
+}
+
+func TestSeatMapLimitsShrink(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	eventID := newTestEvent(t, r, 3)
+
+	_, err := r.CreateSeatMap(ctx, &dto.CreateSeatMap{EventID: &eventID, Name: "stalls",
+		Sections: []dto.SeatSection{{Name: "stalls", Rows: []dto.SeatRow{{Name: "A", Seats: []dto.SeatSpec{{Number: 1}, {Number: 2}}}}}}})
+	if err != nil {
+		t.Fatalf("could not create seat map: %v", err)
+	}
+	event, err := r.GetEventByID(ctx, eventID)
+	if err != nil {
+		t.Fatalf("could not get event: %v", err)
+	}
+
+	tooSmall, fits := 1, 2
+	_, err = r.UpdateEvent(ctx, eventID, &dto.UpdateEvent{TotalSeats: &tooSmall, Version: event.Version})
+	if !errors.Is(err, ErrSeatMapTooLarge) {
+		t.Fatalf("shrinking below the seat map: got %v, want %v", err, ErrSeatMapTooLarge)
+	}
+	updated, err := r.UpdateEvent(ctx, eventID, &dto.UpdateEvent{TotalSeats: &fits, Version: event.Version})
+	if err != nil {
+		t.Fatalf("could not shrink to the seat map: %v", err)
+	}
+	if updated.TotalSeats != fits {
+		t.Fatalf("total_seats = %d, want %d", updated.TotalSeats, fits)
+	}
+}
+
+func TestBookingLimitsPerUser(t *testing.T) {
+	r := newTestRepo(t)
+	ctx := context.Background()
+	const limit, attempts = 3, 20
+	eventID := newTestEvent(t, r, 100)
+	limits := config.Limits{MaxSeatsPerUserPerEvent: limit}
+
+	// every request comes over its own connection, as it would from different replicas
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		booked int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.CreateBooking(ctx, &dto.CreateBooking{EventID: eventID, TelegramID: 7, PlacesCount: 1}, limits)
+			if err != nil {
+				if !errors.Is(err, ErrSeatsPerUserExceeded) {
+					t.Errorf("unexpected booking error: %v", err)
+				}
+				return
+			}
+			mu.Lock()
+			booked++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if booked != limit {
+		t.Fatalf("user booked %d seats, the limit is %d", booked, limit)
+	}
+	assertSeatsConsistent(t, r, eventID)
+}
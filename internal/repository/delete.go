@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+// DeleteEvent removes an event nobody has ever booked, held seats for or waited on, together with
+// its own setup. Events with attendees keep their bookings, payments, refunds and history for good:
+// they are cancelled, never deleted.
+func (r *Postgres) DeleteEvent(ctx context.Context, eventID uuid.UUID) error {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the lock keeps new bookings out until the event is gone
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT id FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEventNotFound
+		}
+		return fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	// money still owed to attendees comes first, so the organizer learns why the event has to stay
+	var owed bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(
+		SELECT 1 FROM refunds r JOIN bookings b ON b.id = r.booking_id WHERE b.event_id = $1 AND r.status <> $2
+	)`, eventID, RefundSucceeded).Scan(&owed)
+	if err != nil {
+		return fmt.Errorf("failed to check event refunds: %w", err)
+	}
+	if owed {
+		return ErrEventHasPendingRefunds
+	}
+
+	var attended bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM bookings WHERE event_id = $1)
+		OR EXISTS(SELECT 1 FROM seat_holds WHERE event_id = $1)
+		OR EXISTS(SELECT 1 FROM waitlist WHERE event_id = $1)`, eventID).Scan(&attended)
+	if err != nil {
+		return fmt.Errorf("failed to check event bookings: %w", err)
+	}
+	if attended {
+		return ErrEventHasBookings
+	}
+
+	for _, query := range []string{
+		`DELETE FROM event_reschedules WHERE event_id = $1`,
+		`DELETE FROM ticket_types WHERE event_id = $1`,
+		`DELETE FROM seats WHERE seat_map_id IN (SELECT id FROM seat_maps WHERE event_id = $1)`,
+		`DELETE FROM seat_maps WHERE event_id = $1`,
+		`DELETE FROM events WHERE id = $1`,
+	} {
+		if _, err = tx.ExecContext(ctx, query, eventID); err != nil {
+			return fmt.Errorf("failed to delete event: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
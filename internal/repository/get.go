@@ -9,21 +9,14 @@ import (
 	"github.com/K1la/event-booker/internal/dto"
 	"github.com/K1la/event-booker/internal/model"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 func (r *Postgres) GetEventByID(ctx context.Context, eventID uuid.UUID) (*model.Event, error) {
-	query := `SELECT * FROM events WHERE id = $1`
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
 
 	var event model.Event
-	err := r.db.QueryRowContext(ctx, query, eventID).Scan(
-		&event.ID,
-		&event.Title,
-		&event.TotalSeats,
-		&event.AvailableSeats,
-		&event.EventAt,
-		&event.CreatedAt,
-		&event.UpdatedAt,
-	)
+	err := r.db.QueryRowContext(ctx, query, eventID).Scan(eventFields(&event)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrEventNotFound
@@ -35,30 +28,46 @@ func (r *Postgres) GetEventByID(ctx context.Context, eventID uuid.UUID) (*model.
 
 func (r *Postgres) GetEvents(ctx context.Context) ([]*model.Event, error) {
 	query := `
-	SELECT 
-		e.id,
-		e.title,
-		e.total_seats,
-		e.available_seats,
-		e.event_at,
-		e.created_at,
-		e.updated_at,
-		COALESCE(json_agg(json_build_object(
-			'id', b.id,
-			'event_id', b.event_id,
-			'status', b.status,
-			'telegram_id', b.telegram_id,
-			'created_at', b.created_at,
-			'updated_at', b.updated_at
-			)) FILTER (WHERE b.id IS NOT NULL), '[]'
-		) AS bookings
-	FROM events e
-	LEFT JOIN bookings b ON b.event_id = e.id
-	GROUP BY e.id
-	ORDER BY e.created_at DESC;
+	SELECT ` + eventColumns + `,
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'id', b.id,
+				'event_id', b.event_id,
+				'places_count', b.places_count,
+				'status', b.status,
+				'telegram_id', b.telegram_id,
+				'ticket_type_id', b.ticket_type_id,
+				'price_minor', b.price_minor,
+				'currency', b.currency,
+				'seat_ids', ARRAY(SELECT seat_id FROM seat_allocations WHERE booking_id = b.id AND status = $1 ORDER BY seat_id),
+				'expires_at', b.expires_at,
+				'created_at', b.created_at,
+				'updated_at', b.updated_at
+			) ORDER BY b.created_at)
+			FROM bookings b
+			WHERE b.event_id = events.id
+		), '[]') AS bookings,
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'id', tt.id,
+				'event_id', tt.event_id,
+				'name', tt.name,
+				'price_minor', tt.price_minor,
+				'currency', tt.currency,
+				'capacity', tt.capacity,
+				'available', tt.available,
+				'pool_id', tt.pool_id,
+				'created_at', tt.created_at,
+				'updated_at', tt.updated_at
+			) ORDER BY tt.price_minor, tt.name)
+			FROM ticket_types tt
+			WHERE tt.event_id = events.id
+		), '[]') AS ticket_types
+	FROM events
+	ORDER BY created_at DESC;
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, AllocationActive)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events from db: %w", err)
 	}
@@ -67,18 +76,9 @@ func (r *Postgres) GetEvents(ctx context.Context) ([]*model.Event, error) {
 	var events []*model.Event
 	for rows.Next() {
 		var e model.Event
-		var bookingsJSON []byte
-
-		if err = rows.Scan(
-			&e.ID,
-			&e.Title,
-			&e.TotalSeats,
-			&e.AvailableSeats,
-			&e.EventAt,
-			&e.CreatedAt,
-			&e.UpdatedAt,
-			&bookingsJSON,
-		); err != nil {
+		var bookingsJSON, ticketTypesJSON []byte
+
+		if err = rows.Scan(append(eventFields(&e), &bookingsJSON, &ticketTypesJSON)...); err != nil {
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 
@@ -86,6 +86,9 @@ func (r *Postgres) GetEvents(ctx context.Context) ([]*model.Event, error) {
 		if err = json.Unmarshal(bookingsJSON, &e.Bookings); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal bookings: %w", err)
 		}
+		if err = json.Unmarshal(ticketTypesJSON, &e.TicketTypes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ticket types: %w", err)
+		}
 
 		events = append(events, &e)
 	}
@@ -98,17 +101,27 @@ func (r *Postgres) GetEvents(ctx context.Context) ([]*model.Event, error) {
 }
 
 func (r *Postgres) GetBookingByID(ctx context.Context, id uuid.UUID) (*dto.Booking, error) {
-	query := `SELECT b.*, e.title
+	query := `SELECT b.id, b.event_id, b.status, b.telegram_id, b.places_count,
+		b.ticket_type_id, b.price_minor, COALESCE(b.currency, ''), b.discount_minor,
+		ARRAY(SELECT seat_id FROM seat_allocations WHERE booking_id = b.id AND status = $2 ORDER BY seat_id),
+		b.expires_at, b.created_at, b.updated_at, e.title
 	FROM bookings b
 	JOIN events e on e.id = b.event_id
 	WHERE b.id = $1`
 
 	var booking dto.Booking
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, AllocationActive).Scan(
 		&booking.ID,
 		&booking.EventID,
 		&booking.Status,
 		&booking.TelegramID,
+		&booking.PlacesCount,
+		&booking.TicketTypeID,
+		&booking.PriceMinor,
+		&booking.Currency,
+		&booking.DiscountMinor,
+		pq.Array(&booking.SeatIDs),
+		&booking.ExpiresAt,
 		&booking.CreatedAt,
 		&booking.UpdatedAt,
 		&booking.EventTitle,
@@ -121,3 +134,32 @@ func (r *Postgres) GetBookingByID(ctx context.Context, id uuid.UUID) (*dto.Booki
 	}
 	return &booking, nil
 }
+
+// GetOverdueBookings returns pending bookings whose payment deadline has passed, oldest deadline first.
+func (r *Postgres) GetOverdueBookings(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	query := `SELECT id FROM bookings
+	WHERE status = $1 AND expires_at <= NOW()
+	ORDER BY expires_at
+	LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, StatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read overdue bookings: %w", err)
+	}
+
+	return ids, nil
+}
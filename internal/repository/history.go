@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	ActorUser   = "user"
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+const (
+	ReasonBookingCreated        = "booking created"
+	ReasonPromotedFromWaitlist  = "promoted from waitlist"
+	ReasonPaymentConfirmed      = "payment confirmed"
+	ReasonPaymentDeadlinePassed = "payment deadline passed"
+	ReasonCancelledByUser       = "cancelled by user"
+	ReasonCancelledByAdmin      = "cancelled by admin"
+	ReasonEventCancelled        = "event cancelled"
+	ReasonOptedOutOfReschedule  = "opted out after reschedule"
+)
+
+// recordHistory appends a status transition of the booking inside tx.
+// from is empty for the creation of the booking.
+func recordHistory(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID, from, to string, change dto.StatusChange) error {
+	query := `INSERT INTO booking_history(booking_id, from_status, to_status, actor, reason)
+	VALUES ($1, NULLIF($2, ''), $3, $4, $5)`
+
+	if _, err := tx.ExecContext(ctx, query, bookingID, from, to, change.Actor, change.Reason); err != nil {
+		return fmt.Errorf("failed to record booking history: %w", err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetBookingHistory(ctx context.Context, bookingID uuid.UUID) ([]*model.BookingHistoryEntry, error) {
+	query := `SELECT id, booking_id, COALESCE(from_status, ''), to_status, actor, reason, created_at
+	FROM booking_history
+	WHERE booking_id = $1
+	ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*model.BookingHistoryEntry
+	for rows.Next() {
+		var entry model.BookingHistoryEntry
+		if err = rows.Scan(
+			&entry.ID,
+			&entry.BookingID,
+			&entry.FromStatus,
+			&entry.ToStatus,
+			&entry.Actor,
+			&entry.Reason,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		history = append(history, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read booking history: %w", err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+const holdColumns = `id, event_id, ticket_type_id, COALESCE(telegram_id, 0), places_count,
+	ARRAY(SELECT seat_id FROM seat_allocations WHERE hold_id = seat_holds.id AND status = '` + AllocationActive + `' ORDER BY seat_id),
+	status, booking_id, expires_at, created_at, updated_at`
+
+func holdFields(h *model.SeatHold) []any {
+	return []any{
+		&h.ID,
+		&h.EventID,
+		&h.TicketTypeID,
+		&h.TelegramID,
+		&h.PlacesCount,
+		pq.Array(&h.SeatIDs),
+		&h.Status,
+		&h.BookingID,
+		&h.ExpiresAt,
+		&h.CreatedAt,
+		&h.UpdatedAt,
+	}
+}
+
+// CreateHold takes the seats the same way a booking does and keeps them for ttl.
+// Holds count against the same limits as bookings.
+func (r *Postgres) CreateHold(ctx context.Context, hold *dto.CreateHold, ttl time.Duration, limits config.Limits) (*model.SeatHold, error) {
+	if hold.PlacesCount <= 0 {
+		return nil, ErrInvalidPlacesCount
+	}
+	seatIDs, err := selectedSeats(hold.PlacesCount, hold.SeatIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = checkBookingLimits(ctx, tx, limits, hold.TelegramID, hold.EventID, hold.PlacesCount); err != nil {
+		return nil, err
+	}
+
+	if err = checkSeatSelection(ctx, tx, hold.EventID, seatIDs); err != nil {
+		return nil, err
+	}
+
+	if err = reserveSeats(ctx, tx, hold.EventID, hold.TicketTypeID, hold.PlacesCount); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO seat_holds(event_id, ticket_type_id, telegram_id, places_count, expires_at)
+	VALUES ($1, $2, NULLIF($3, 0), $4, NOW() + make_interval(secs => $5))
+	RETURNING ` + holdColumns
+
+	var created model.SeatHold
+	err = tx.QueryRowContext(ctx, query, hold.EventID, hold.TicketTypeID, hold.TelegramID, hold.PlacesCount,
+		ttl.Seconds()).Scan(holdFields(&created)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seat hold: %w", err)
+	}
+
+	if len(seatIDs) > 0 {
+		if created.SeatIDs, err = allocateSeats(ctx, tx, hold.EventID, seatOwner{holdID: &created.ID}, seatIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *Postgres) GetHoldByID(ctx context.Context, id uuid.UUID) (*model.SeatHold, error) {
+	var hold model.SeatHold
+	err := r.db.QueryRowContext(ctx, `SELECT `+holdColumns+` FROM seat_holds WHERE id = $1`, id).Scan(holdFields(&hold)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchHold
+		}
+		return nil, fmt.Errorf("failed to get seat hold: %w", err)
+	}
+	return &hold, nil
+}
+
+// GetHeldSeats returns how many seats of the event are in active holds.
+func (r *Postgres) GetHeldSeats(ctx context.Context, eventID uuid.UUID) (int, error) {
+	var held int
+	err := r.db.QueryRowContext(ctx, `SELECT COALESCE(SUM(places_count), 0)
+	FROM seat_holds
+	WHERE event_id = $1 AND status = $2`, eventID, HoldActive).Scan(&held)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum held seats: %w", err)
+	}
+	return held, nil
+}
+
+// lockActiveHold locks the hold until the end of tx. Only an active hold that has not run out may be used,
+// so the sweeper and the user race for it the same way the expiry worker and the user race for a booking.
+// Like a booking, the hold is locked after its event.
+func lockActiveHold(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*model.SeatHold, error) {
+	_, err := tx.ExecContext(ctx, `SELECT 1 FROM events WHERE id = (SELECT event_id FROM seat_holds WHERE id = $1) FOR UPDATE`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock event of seat hold: %w", err)
+	}
+
+	var hold model.SeatHold
+	var expired bool
+	err = tx.QueryRowContext(ctx, `SELECT `+holdColumns+`, expires_at <= NOW()
+	FROM seat_holds WHERE id = $1 FOR UPDATE`, id).Scan(append(holdFields(&hold), &expired)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchHold
+		}
+		return nil, fmt.Errorf("failed to lock seat hold: %w", err)
+	}
+
+	switch {
+	case hold.Status == HoldExpired:
+		return nil, ErrHoldExpired
+	case hold.Status != HoldActive:
+		return nil, ErrHoldNotActive
+	case expired:
+		return nil, ErrHoldExpired
+	}
+	return &hold, nil
+}
+
+// ConvertHold turns an active hold into a pending booking. The seats, and the exact seats
+// of a seat map, move from the hold to the booking without going back to the event in between.
+// The event must still be on sale, as it must for a new booking: a hold does not outlive
+// the sales window or the event being taken off sale.
+func (r *Postgres) ConvertHold(ctx context.Context, holdID uuid.UUID, req *dto.ConvertHold) (*model.Booking, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockActiveHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	// lockActiveHold has locked the event, so it can not go off sale until the booking is in
+	if err = eventSaleError(ctx, tx, hold.EventID); err != nil {
+		return nil, err
+	}
+
+	booking, err := insertBooking(ctx, tx, hold.EventID, hold.TicketTypeID, hold.TelegramID, hold.PlacesCount,
+		dto.StatusChange{Actor: ActorUser, Reason: ReasonBookingCreated})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PromoCode != "" {
+		if booking, err = applyPromoCode(ctx, tx, booking, req.PromoCode); err != nil {
+			return nil, err
+		}
+	}
+
+	if booking.SeatIDs, err = moveHoldSeats(ctx, tx, holdID, booking.ID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE seat_holds
+	SET status = $2,
+	    booking_id = $3,
+	    updated_at = NOW()
+	WHERE id = $1`, holdID, HoldConverted, booking.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert seat hold: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return booking, nil
+}
+
+// ReleaseHold gives the seats of an active hold back when the user changes their mind.
+func (r *Postgres) ReleaseHold(ctx context.Context, holdID uuid.UUID) (*model.SeatHold, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockActiveHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	released, err := endHold(ctx, tx, hold, HoldReleased)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return released, nil
+}
+
+// ExpireHolds lapses up to limit holds that have run out and returns their seats.
+// The events are locked before their holds, as everywhere else; holds of an event that is busy,
+// e.g. with a conversion or a cancellation, are skipped and will be seen again on the next run
+// if they are still active. It returns the events that got seats back.
+func (r *Postgres) ExpireHolds(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	eventRows, err := tx.QueryContext(ctx, `SELECT id
+	FROM events
+	WHERE id IN (SELECT event_id FROM seat_holds WHERE status = $1 AND expires_at <= NOW())
+	ORDER BY id
+	FOR UPDATE SKIP LOCKED`, HoldActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock events of expired seat holds: %w", err)
+	}
+
+	var lockedEvents []uuid.UUID
+	for eventRows.Next() {
+		var id uuid.UUID
+		if err = eventRows.Scan(&id); err != nil {
+			eventRows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		lockedEvents = append(lockedEvents, id)
+	}
+	eventRows.Close()
+	if err = eventRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events of expired seat holds: %w", err)
+	}
+	if len(lockedEvents) == 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+holdColumns+`
+	FROM seat_holds
+	WHERE status = $1 AND expires_at <= NOW() AND event_id = ANY($3)
+	ORDER BY expires_at
+	LIMIT $2
+	FOR UPDATE`, HoldActive, limit, pq.Array(lockedEvents))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired seat holds: %w", err)
+	}
+
+	var holds []*model.SeatHold
+	for rows.Next() {
+		var hold model.SeatHold
+		if err = rows.Scan(holdFields(&hold)...); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		holds = append(holds, &hold)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read expired seat holds: %w", err)
+	}
+
+	var eventIDs []uuid.UUID
+	seen := make(map[uuid.UUID]struct{})
+	for _, hold := range holds {
+		if _, err = endHold(ctx, tx, hold, HoldExpired); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[hold.EventID]; !ok {
+			seen[hold.EventID] = struct{}{}
+			eventIDs = append(eventIDs, hold.EventID)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return eventIDs, nil
+}
+
+// endHold moves a locked active hold to status and returns its seats inside tx.
+func endHold(ctx context.Context, tx *sql.Tx, hold *model.SeatHold, status string) (*model.SeatHold, error) {
+	if err := releaseHoldSeats(ctx, tx, hold.ID); err != nil {
+		return nil, err
+	}
+
+	var ended model.SeatHold
+	err := tx.QueryRowContext(ctx, `UPDATE seat_holds
+	SET status = $2,
+	    updated_at = NOW()
+	WHERE id = $1
+	RETURNING `+holdColumns, hold.ID, status).Scan(holdFields(&ended)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to end seat hold: %w", err)
+	}
+
+	if err = returnSeats(ctx, tx, hold.EventID, hold.TicketTypeID, hold.PlacesCount); err != nil {
+		return nil, err
+	}
+
+	return &ended, nil
+}
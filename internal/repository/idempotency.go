@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/model"
+	"time"
+)
+
+// reserveIdempotencyKeyAttempts bounds the retries of a key that disappears between the insert and the read.
+const reserveIdempotencyKeyAttempts = 3
+
+// ReserveIdempotencyKey claims the key for a new request for lease. A key whose request is still
+// unanswered after its lease ran out, e.g. because the replica handling it crashed, is taken over
+// by a retry of the same request. If the key is taken, the stored record is returned instead
+// and the caller decides whether to replay it.
+func (r *Postgres) ReserveIdempotencyKey(ctx context.Context, scope, key, fingerprint string, lease time.Duration) (*model.IdempotencyRecord, bool, error) {
+	insertQuery := `INSERT INTO idempotency_keys(scope, key, fingerprint, locked_until)
+	VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+	ON CONFLICT (scope, key) DO NOTHING`
+
+	takeOverQuery := `UPDATE idempotency_keys
+	SET locked_until = NOW() + make_interval(secs => $4)
+	WHERE scope = $1 AND key = $2 AND fingerprint = $3 AND status_code IS NULL AND locked_until <= NOW()`
+
+	selectQuery := `SELECT scope, key, fingerprint, status_code, response, created_at
+	FROM idempotency_keys
+	WHERE scope = $1 AND key = $2`
+
+	for attempt := 0; attempt < reserveIdempotencyKeyAttempts; attempt++ {
+		for _, query := range []string{insertQuery, takeOverQuery} {
+			result, err := r.db.ExecContext(ctx, query, scope, key, fingerprint, lease.Seconds())
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+			}
+			if rowsAffected == 1 {
+				return nil, true, nil
+			}
+		}
+
+		var (
+			record     model.IdempotencyRecord
+			statusCode sql.NullInt64
+		)
+		err := r.db.QueryRowContext(ctx, selectQuery, scope, key).Scan(
+			&record.Scope,
+			&record.Key,
+			&record.Fingerprint,
+			&statusCode,
+			&record.Response,
+			&record.CreatedAt,
+		)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// removed by cleanup or a failed request between the two statements
+				continue
+			}
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		record.StatusCode = int(statusCode.Int64)
+
+		return &record, false, nil
+	}
+
+	// the key keeps being claimed and released by other requests
+	return nil, false, ErrIdempotencyKeyInProgress
+}
+
+func (r *Postgres) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, response []byte) error {
+	query := `UPDATE idempotency_keys
+	SET status_code = $3,
+	    response = $4,
+	    completed_at = NOW()
+	WHERE scope = $1 AND key = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, key, statusCode, response); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a key whose request failed, so the client may retry with it.
+func (r *Postgres) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *Postgres) DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE created_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, time.Now().Add(-ttl))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+// SetEventStatus moves the event along its lifecycle. Cancellation has its own
+// path (CancelEvent), because it also cancels the bookings and notifies attendees.
+func (r *Postgres) SetEventStatus(ctx context.Context, eventID uuid.UUID, status string) (*model.Event, error) {
+	if status == EventCancelled {
+		return nil, fmt.Errorf("%w: use event cancellation", ErrInvalidEventTransition)
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current model.Event
+	err = tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(
+		eventFields(&current)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	if !CanEventTransition(current.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidEventTransition, current.Status, status)
+	}
+	if status == EventCompleted && current.EventAt.After(time.Now()) {
+		return nil, ErrEventNotFinished
+	}
+
+	query := `UPDATE events
+	SET status = $1,
+	    version = version + 1,
+	    updated_at = NOW()
+	WHERE id = $2
+	RETURNING ` + eventColumns
+
+	var updated model.Event
+	if err = tx.QueryRowContext(ctx, query, status, eventID).Scan(eventFields(&updated)...); err != nil {
+		return nil, fmt.Errorf("failed to set event status %s: %w", status, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// CompletePastEvents moves every published or sales closed event whose time has passed to completed.
+func (r *Postgres) CompletePastEvents(ctx context.Context) (int64, error) {
+	query := `UPDATE events
+	SET status = $1,
+	    version = version + 1,
+	    updated_at = NOW()
+	WHERE status IN ($2, $3) AND event_at <= NOW()`
+
+	result, err := r.db.ExecContext(ctx, query, EventCompleted, EventPublished, EventSalesClosed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete past events: %w", err)
+	}
+
+	completed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete past events: %w", err)
+	}
+
+	return completed, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/config"
+	"github.com/google/uuid"
+)
+
+// userLockClass keeps the advisory locks of users apart from any other advisory locks in the database.
+const userLockClass = 1
+
+func perUserLimits(limits config.Limits) bool {
+	return limits.MaxSeatsPerUserPerEvent > 0 || limits.MaxPendingPerUser > 0
+}
+
+// checkBookingLimits rejects a request for places seats of the event that would break limits.
+// Per-user limits count the user's pending and confirmed bookings and active holds, so they need
+// a telegram_id. The user's advisory lock is held until tx ends: requests of one user serialize
+// on every replica and can not each pass the check and together go over a limit.
+func checkBookingLimits(ctx context.Context, tx *sql.Tx, limits config.Limits, telegramID int, eventID uuid.UUID, places int) error {
+	if limits.MaxSeatsPerBooking > 0 && places > limits.MaxSeatsPerBooking {
+		return fmt.Errorf("%w: %d of at most %d", ErrSeatsPerBookingExceeded, places, limits.MaxSeatsPerBooking)
+	}
+	if !perUserLimits(limits) {
+		return nil
+	}
+	if telegramID <= 0 {
+		return ErrTelegramIDRequired
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, userLockClass, telegramID); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	return checkUserHoldings(ctx, tx, limits, telegramID, eventID, places)
+}
+
+// fitsBookingLimits reports whether a waitlist entry may become a booking. The promotion already
+// holds the event row, so it never waits for the user's lock, which a booking takes before the
+// event: an entry of a user busy with another request is passed over this time and keeps its place.
+func fitsBookingLimits(ctx context.Context, tx *sql.Tx, limits config.Limits, telegramID int, eventID uuid.UUID, places int) (bool, error) {
+	if limits.MaxSeatsPerBooking > 0 && places > limits.MaxSeatsPerBooking {
+		return false, nil
+	}
+	// entries without a user were taken while per-user limits were off
+	if !perUserLimits(limits) || telegramID <= 0 {
+		return true, nil
+	}
+
+	var locked bool
+	err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1, $2)`, userLockClass, telegramID).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock user: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	if err = checkUserHoldings(ctx, tx, limits, telegramID, eventID, places); err != nil {
+		if isBookingLimitError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// checkUserHoldings compares what the user already holds with the per-user limits.
+func checkUserHoldings(ctx context.Context, tx *sql.Tx, limits config.Limits, telegramID int, eventID uuid.UUID, places int) error {
+	query := `SELECT
+		COALESCE(SUM(places_count) FILTER (WHERE event_id = $2), 0),
+		COUNT(*) FILTER (WHERE unpaid)
+	FROM (
+		SELECT event_id, places_count, status = $3 AS unpaid
+		FROM bookings
+		WHERE telegram_id = $1 AND status IN ($3, $4)
+		UNION ALL
+		SELECT event_id, places_count, true
+		FROM seat_holds
+		WHERE telegram_id = $1 AND status = $5
+	) held`
+
+	var seats, pending int
+	err := tx.QueryRowContext(ctx, query, telegramID, eventID, StatusPending, StatusConfirmed, HoldActive).Scan(&seats, &pending)
+	if err != nil {
+		return fmt.Errorf("failed to get user bookings: %w", err)
+	}
+
+	if limits.MaxSeatsPerUserPerEvent > 0 && seats+places > limits.MaxSeatsPerUserPerEvent {
+		return fmt.Errorf("%w: %d held, %d requested, at most %d",
+			ErrSeatsPerUserExceeded, seats, places, limits.MaxSeatsPerUserPerEvent)
+	}
+	if limits.MaxPendingPerUser > 0 && pending >= limits.MaxPendingPerUser {
+		return fmt.Errorf("%w: at most %d", ErrPendingBookingsExceeded, limits.MaxPendingPerUser)
+	}
+
+	return nil
+}
+
+func isBookingLimitError(err error) bool {
+	return errors.Is(err, ErrSeatsPerUserExceeded) || errors.Is(err, ErrPendingBookingsExceeded)
+}
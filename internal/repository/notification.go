@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationQueued = "queued"
+	NotificationSent   = "sent"
+	NotificationFailed = "failed"
+)
+
+// notificationColumns is the column list scanNotification expects, in order.
+const notificationColumns = `id, event_id, telegram_id, text, status, attempts, COALESCE(last_error, ''), sent_at, created_at, updated_at`
+
+func scanNotification(rows *sql.Rows) (*model.Notification, error) {
+	var n model.Notification
+	err := rows.Scan(
+		&n.ID,
+		&n.EventID,
+		&n.TelegramID,
+		&n.Text,
+		&n.Status,
+		&n.Attempts,
+		&n.LastError,
+		&n.SentAt,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	return &n, nil
+}
+
+// enqueueNotification queues a Telegram message inside tx; the notifier delivers it after commit.
+func enqueueNotification(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, telegramID int, text string) error {
+	query := `INSERT INTO notifications(event_id, telegram_id, text, status) VALUES ($1, $2, $3, $4)`
+
+	if _, err := tx.ExecContext(ctx, query, eventID, telegramID, text, NotificationQueued); err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetQueuedNotifications(ctx context.Context, limit int) ([]*model.Notification, error) {
+	query := `SELECT ` + notificationColumns + `
+	FROM notifications
+	WHERE status = $1
+	ORDER BY created_at
+	LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, NotificationQueued, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*model.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (r *Postgres) MarkNotificationSent(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE notifications
+	SET status = $2,
+	    attempts = attempts + 1,
+	    last_error = NULL,
+	    sent_at = NOW(),
+	    updated_at = NOW()
+	WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, NotificationSent); err != nil {
+		return fmt.Errorf("failed to mark notification sent: %w", err)
+	}
+	return nil
+}
+
+// MarkNotificationFailed records a failed delivery. The notification stays queued for another
+// attempt until maxAttempts is reached, then it is failed for good.
+func (r *Postgres) MarkNotificationFailed(ctx context.Context, id uuid.UUID, cause error, maxAttempts int) error {
+	query := `UPDATE notifications
+	SET attempts = attempts + 1,
+	    last_error = $2,
+	    status = CASE WHEN attempts + 1 >= $3 THEN $4 ELSE status END,
+	    updated_at = NOW()
+	WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, cause.Error(), maxAttempts, NotificationFailed); err != nil {
+		return fmt.Errorf("failed to mark notification failed: %w", err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetEventNotifications(ctx context.Context, eventID uuid.UUID) (*model.NotificationReport, error) {
+	if _, err := r.GetEventByID(ctx, eventID); err != nil {
+		return nil, err
+	}
+
+	report := model.NotificationReport{EventID: eventID, Failures: []*model.Notification{}}
+	err := r.db.QueryRowContext(ctx, `SELECT
+		COUNT(*),
+		COUNT(*) FILTER (WHERE status = $2),
+		COUNT(*) FILTER (WHERE status = $3),
+		COUNT(*) FILTER (WHERE status = $4)
+	FROM notifications
+	WHERE event_id = $1`, eventID, NotificationQueued, NotificationSent, NotificationFailed).Scan(
+		&report.Total, &report.Queued, &report.Sent, &report.Failed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+notificationColumns+`
+	FROM notifications
+	WHERE event_id = $1 AND status = $2
+	ORDER BY created_at`, eventID, NotificationFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed notifications: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		report.Failures = append(report.Failures, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+
+	return &report, nil
+}
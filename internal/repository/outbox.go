@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+// enqueueExpiry writes the booking's expiry message to the outbox inside tx,
+// so the message exists if and only if the booking was committed.
+func enqueueExpiry(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID, deliverAt time.Time) error {
+	query := `INSERT INTO outbox(booking_id, deliver_at) VALUES ($1, $2)`
+
+	if _, err := tx.ExecContext(ctx, query, bookingID, deliverAt); err != nil {
+		return fmt.Errorf("failed to enqueue booking expiry: %w", err)
+	}
+	return nil
+}
+
+func (r *Postgres) GetUnpublishedOutbox(ctx context.Context, limit int) ([]*model.OutboxMessage, error) {
+	query := `SELECT id, booking_id, deliver_at, attempts, created_at
+	FROM outbox
+	WHERE published_at IS NULL
+	ORDER BY created_at
+	LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*model.OutboxMessage
+	for rows.Next() {
+		var m model.OutboxMessage
+		if err = rows.Scan(&m.ID, &m.BookingID, &m.DeliverAt, &m.Attempts, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		messages = append(messages, &m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *Postgres) MarkOutboxPublished(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE outbox
+	SET published_at = NOW(),
+	    attempts = attempts + 1,
+	    last_error = NULL
+	WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to mark outbox message published: %w", err)
+	}
+	return nil
+}
+
+func (r *Postgres) MarkOutboxFailed(ctx context.Context, id uuid.UUID, cause error) error {
+	query := `UPDATE outbox
+	SET attempts = attempts + 1,
+	    last_error = $2
+	WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, cause.Error()); err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+	return nil
+}
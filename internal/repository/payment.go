@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+)
+
+const paymentColumns = `id, booking_id, provider, provider_payment_id, amount_minor, currency, status, checkout_url, created_at, updated_at`
+
+func paymentFields(p *model.Payment) []any {
+	return []any{
+		&p.ID,
+		&p.BookingID,
+		&p.Provider,
+		&p.ProviderPaymentID,
+		&p.AmountMinor,
+		&p.Currency,
+		&p.Status,
+		&p.CheckoutURL,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	}
+}
+
+func (r *Postgres) CreatePayment(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
+	query := `INSERT INTO payments(booking_id, provider, provider_payment_id, amount_minor, currency, status, checkout_url)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING ` + paymentColumns
+
+	var created model.Payment
+	err := r.db.QueryRowContext(ctx, query,
+		payment.BookingID,
+		payment.Provider,
+		payment.ProviderPaymentID,
+		payment.AmountMinor,
+		payment.Currency,
+		PaymentPending,
+		payment.CheckoutURL,
+	).Scan(paymentFields(&created)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	return &created, nil
+}
+
+// GetPendingPayment returns the latest payment of the booking that is still waiting for the provider.
+func (r *Postgres) GetPendingPayment(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+	FROM payments
+	WHERE booking_id = $1 AND status = $2
+	ORDER BY created_at DESC
+	LIMIT 1`
+
+	var payment model.Payment
+	err := r.db.QueryRowContext(ctx, query, bookingID, PaymentPending).Scan(paymentFields(&payment)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchPayment
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return &payment, nil
+}
+
+func (r *Postgres) GetPaymentByProviderID(ctx context.Context, provider, providerPaymentID string) (*model.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE provider = $1 AND provider_payment_id = $2`
+
+	var payment model.Payment
+	err := r.db.QueryRowContext(ctx, query, provider, providerPaymentID).Scan(paymentFields(&payment)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchPayment
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return &payment, nil
+}
+
+// CompletePayment marks the payment succeeded and confirms its booking in one transaction.
+// A payment that is no longer pending is returned as is, so repeated webhooks change nothing.
+// If the booking left pending before the money arrived (it expired or was cancelled),
+// the payment is still recorded, a full refund is queued and the returned booking is nil.
+func (r *Postgres) CompletePayment(ctx context.Context, paymentID uuid.UUID) (*model.Payment, *model.Booking, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the booking's event is locked before the payment, in the order lockBookingStatus takes it
+	_, err = tx.ExecContext(ctx, `SELECT 1 FROM events
+	WHERE id = (SELECT b.event_id FROM payments p JOIN bookings b ON b.id = p.booking_id WHERE p.id = $1)
+	FOR UPDATE`, paymentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to lock event of payment: %w", err)
+	}
+
+	var payment model.Payment
+	err = tx.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = $1 FOR UPDATE`, paymentID).Scan(
+		paymentFields(&payment)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNoSuchPayment
+		}
+		return nil, nil, fmt.Errorf("failed to lock payment: %w", err)
+	}
+	if payment.Status != PaymentPending {
+		return &payment, nil, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `UPDATE payments
+	SET status = $1,
+	    updated_at = NOW()
+	WHERE id = $2
+	RETURNING `+paymentColumns, PaymentSucceeded, paymentID).Scan(paymentFields(&payment)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to complete payment: %w", err)
+	}
+
+	from, err := lockBookingStatus(ctx, tx, payment.BookingID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var booking *model.Booking
+	if CanTransition(from, StatusConfirmed) {
+		booking, err = setBookingStatus(ctx, tx, payment.BookingID, from, StatusConfirmed, dto.StatusChange{
+			Actor:  ActorSystem,
+			Reason: ReasonPaymentConfirmed,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// the seats are gone, so the money goes back
+		if err = queueRefund(ctx, tx, payment.BookingID, FullRefundPercent, RefundReasonLatePayment); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &payment, booking, nil
+}
+
+// FailPayment marks a pending payment failed. The booking stays pending, so the attendee
+// may pay again until the payment deadline.
+func (r *Postgres) FailPayment(ctx context.Context, paymentID uuid.UUID) error {
+	query := `UPDATE payments
+	SET status = $1,
+	    updated_at = NOW()
+	WHERE id = $2 AND status = $3`
+
+	if _, err := r.db.ExecContext(ctx, query, PaymentFailed, paymentID, PaymentPending); err != nil {
+		return fmt.Errorf("failed to mark payment failed: %w", err)
+	}
+	return nil
+}
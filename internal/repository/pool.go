@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const poolColumns = `id, name, capacity, available,
+	ARRAY(SELECT id FROM events WHERE pool_id = capacity_pools.id ORDER BY event_at),
+	ARRAY(SELECT id FROM ticket_types WHERE pool_id = capacity_pools.id ORDER BY name),
+	created_at, updated_at`
+
+func poolFields(p *model.CapacityPool) []any {
+	return []any{
+		&p.ID,
+		&p.Name,
+		&p.Capacity,
+		&p.Available,
+		pq.Array(&p.EventIDs),
+		pq.Array(&p.TicketTypeIDs),
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	}
+}
+
+func (r *Postgres) CreateCapacityPool(ctx context.Context, req *dto.CreateCapacityPool) (*model.CapacityPool, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || req.Capacity <= 0 {
+		return nil, ErrInvalidPool
+	}
+
+	query := `INSERT INTO capacity_pools(name, capacity, available)
+	VALUES ($1, $2, $2)
+	RETURNING ` + poolColumns
+
+	var created model.CapacityPool
+	if err := r.db.QueryRowContext(ctx, query, name, req.Capacity).Scan(poolFields(&created)...); err != nil {
+		return nil, fmt.Errorf("failed to create capacity pool: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *Postgres) GetCapacityPools(ctx context.Context) ([]*model.CapacityPool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+poolColumns+` FROM capacity_pools ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capacity pools: %w", err)
+	}
+	defer rows.Close()
+
+	pools := []*model.CapacityPool{}
+	for rows.Next() {
+		var pool model.CapacityPool
+		if err = rows.Scan(poolFields(&pool)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		pools = append(pools, &pool)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read capacity pools: %w", err)
+	}
+
+	return pools, nil
+}
+
+func (r *Postgres) GetCapacityPoolByID(ctx context.Context, id uuid.UUID) (*model.CapacityPool, error) {
+	var pool model.CapacityPool
+	err := r.db.QueryRowContext(ctx, `SELECT `+poolColumns+` FROM capacity_pools WHERE id = $1`, id).Scan(poolFields(&pool)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchPool
+		}
+		return nil, fmt.Errorf("failed to get capacity pool: %w", err)
+	}
+	return &pool, nil
+}
+
+// GetPoolEventIDs returns every event that draws seats from the pool, directly or through a ticket type,
+// unlike the EventIDs of the pool, which only lists the events linked directly.
+func (r *Postgres) GetPoolEventIDs(ctx context.Context, poolID uuid.UUID) ([]uuid.UUID, error) {
+	query := `SELECT id FROM events WHERE pool_id = $1
+	UNION
+	SELECT event_id FROM ticket_types WHERE pool_id = $1`
+
+	rows, err := r.db.QueryContext(ctx, query, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool events: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pool events: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetPooledEventIDs returns the event and every other event that draws seats from one of its pools,
+// directly or through a ticket type. Seats given back to a pool may let any of them in.
+func (r *Postgres) GetPooledEventIDs(ctx context.Context, eventID uuid.UUID) ([]uuid.UUID, error) {
+	query := `WITH pools AS (
+		SELECT pool_id FROM events WHERE id = $1 AND pool_id IS NOT NULL
+		UNION
+		SELECT pool_id FROM ticket_types WHERE event_id = $1 AND pool_id IS NOT NULL
+	)
+	SELECT id FROM events WHERE pool_id IN (SELECT pool_id FROM pools) AND id <> $1
+	UNION
+	SELECT event_id FROM ticket_types WHERE pool_id IN (SELECT pool_id FROM pools) AND event_id <> $1`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pooled events: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{eventID}
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pooled events: %w", err)
+	}
+
+	return ids, nil
+}
+
+// UpdateCapacityPool renames or resizes the pool. Resizing keeps the seats already taken:
+// available seats move by the same delta as the capacity.
+func (r *Postgres) UpdateCapacityPool(ctx context.Context, id uuid.UUID, upd *dto.UpdateCapacityPool) (*model.CapacityPool, error) {
+	if upd.Name != nil && strings.TrimSpace(*upd.Name) == "" {
+		return nil, ErrInvalidPool
+	}
+	if upd.Capacity != nil && *upd.Capacity <= 0 {
+		return nil, ErrInvalidPool
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pool model.CapacityPool
+	err = tx.QueryRowContext(ctx, `SELECT `+poolColumns+` FROM capacity_pools WHERE id = $1 FOR UPDATE`, id).Scan(
+		poolFields(&pool)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchPool
+		}
+		return nil, fmt.Errorf("failed to lock capacity pool: %w", err)
+	}
+
+	name, capacity, available := pool.Name, pool.Capacity, pool.Available
+	if upd.Name != nil {
+		name = strings.TrimSpace(*upd.Name)
+	}
+	if upd.Capacity != nil {
+		taken := pool.Capacity - pool.Available
+		if *upd.Capacity < taken {
+			return nil, ErrPoolCapacityBelowUsed
+		}
+		capacity = *upd.Capacity
+		available = capacity - taken
+	}
+
+	var updated model.CapacityPool
+	err = tx.QueryRowContext(ctx, `UPDATE capacity_pools
+	SET name = $2,
+	    capacity = $3,
+	    available = $4,
+	    updated_at = NOW()
+	WHERE id = $1
+	RETURNING `+poolColumns, id, name, capacity, available).Scan(poolFields(&updated)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update capacity pool: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// lockPools locks the pools the event and the ticket type draw from, in id order so that
+// bookings of different events sharing pools can not deadlock. It returns the pool ids and
+// the fewest seats left in any of them.
+func lockPools(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID) ([]uuid.UUID, int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, available
+	FROM capacity_pools
+	WHERE id IN (SELECT pool_id FROM events WHERE id = $1
+	             UNION
+	             SELECT pool_id FROM ticket_types WHERE id = $2)
+	ORDER BY id
+	FOR UPDATE`, eventID, ticketTypeID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to lock capacity pools: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	fewest := 0
+	for rows.Next() {
+		var id uuid.UUID
+		var available int
+		if err = rows.Scan(&id, &available); err != nil {
+			return nil, 0, fmt.Errorf("scan failed: %w", err)
+		}
+		if len(ids) == 0 || available < fewest {
+			fewest = available
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read capacity pools: %w", err)
+	}
+
+	return ids, fewest, nil
+}
+
+// availablePoolSeats returns how many seats the pools of the event and the ticket type still have
+// and whether they have pools at all. The pools stay locked until the end of tx.
+func availablePoolSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID) (int, bool, error) {
+	ids, available, err := lockPools(ctx, tx, eventID, ticketTypeID)
+	if err != nil {
+		return 0, false, err
+	}
+	return available, len(ids) > 0, nil
+}
+
+// takePoolSeats takes places seats from every pool the event and the ticket type draw from.
+// A pool both of them use gives its seats once.
+func takePoolSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID, places int) error {
+	ids, available, err := lockPools(ctx, tx, eventID, ticketTypeID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if available < places {
+		return fmt.Errorf("%w: %w", ErrNoSeatsAvailable, ErrPoolExhausted)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE capacity_pools
+	SET available = available - $1,
+	    updated_at = NOW()
+	WHERE id = ANY($2)`, places, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to take pool seats: %w", err)
+	}
+	return nil
+}
+
+// returnPoolSeats gives places seats back to the pools the event and the ticket type draw from.
+func returnPoolSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID, places int) error {
+	ids, _, err := lockPools(ctx, tx, eventID, ticketTypeID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE capacity_pools
+	SET available = available + $1,
+	    updated_at = NOW()
+	WHERE id = ANY($2)`, places, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to return pool seats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"slices"
+	"strings"
+	"time"
+)
+
+const promoCodeColumns = `id, code, discount_type, discount_value, COALESCE(currency, ''), max_uses, used_count, expires_at,
+	event_ids, ticket_type_ids, created_at, updated_at`
+
+func promoCodeFields(p *model.PromoCode) []any {
+	return []any{
+		&p.ID,
+		&p.Code,
+		&p.DiscountType,
+		&p.DiscountValue,
+		&p.Currency,
+		&p.MaxUses,
+		&p.UsedCount,
+		&p.ExpiresAt,
+		pq.Array(&p.EventIDs),
+		pq.Array(&p.TicketTypeIDs),
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	}
+}
+
+// normalizePromoCode makes codes case-insensitive, so "summer25" and "SUMMER25" are the same code.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (r *Postgres) CreatePromoCode(ctx context.Context, req *dto.CreatePromoCode) (*model.PromoCode, error) {
+	code := normalizePromoCode(req.Code)
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+
+	valid := code != "" && req.DiscountValue > 0 &&
+		(req.MaxUses == nil || *req.MaxUses > 0) &&
+		(req.ExpiresAt == nil || req.ExpiresAt.After(time.Now()))
+	switch req.DiscountType {
+	case PromoPercent:
+		valid = valid && req.DiscountValue <= 100
+		currency = ""
+	case PromoFixed:
+		valid = valid && len(currency) == 3
+	default:
+		valid = false
+	}
+	if !valid {
+		return nil, ErrInvalidPromoCode
+	}
+
+	eventIDs := uniqueIDs(req.EventIDs)
+	ticketTypeIDs := uniqueIDs(req.TicketTypeIDs)
+
+	var events, ticketTypes int
+	err := r.db.QueryRowContext(ctx, `SELECT
+		(SELECT COUNT(*) FROM events WHERE id = ANY($1)),
+		(SELECT COUNT(*) FROM ticket_types WHERE id = ANY($2))`,
+		pq.Array(eventIDs), pq.Array(ticketTypeIDs)).Scan(&events, &ticketTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check promo code restrictions: %w", err)
+	}
+	if events != len(eventIDs) {
+		return nil, ErrEventNotFound
+	}
+	if ticketTypes != len(ticketTypeIDs) {
+		return nil, ErrNoSuchTicketType
+	}
+
+	query := `INSERT INTO promo_codes(code, discount_type, discount_value, currency, max_uses, expires_at, event_ids, ticket_type_ids)
+	VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8)
+	RETURNING ` + promoCodeColumns
+
+	var created model.PromoCode
+	err = r.db.QueryRowContext(ctx, query,
+		code,
+		req.DiscountType,
+		req.DiscountValue,
+		currency,
+		req.MaxUses,
+		req.ExpiresAt,
+		pq.Array(eventIDs),
+		pq.Array(ticketTypeIDs),
+	).Scan(promoCodeFields(&created)...)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrPromoCodeExists
+		}
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	return &created, nil
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func (r *Postgres) GetPromoCodes(ctx context.Context) ([]*model.PromoCode, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+promoCodeColumns+` FROM promo_codes ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := []*model.PromoCode{}
+	for rows.Next() {
+		var code model.PromoCode
+		if err = rows.Scan(promoCodeFields(&code)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		codes = append(codes, &code)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read promo codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (r *Postgres) GetPromoCodeReport(ctx context.Context, id uuid.UUID) (*model.PromoCodeReport, error) {
+	var code model.PromoCode
+	err := r.db.QueryRowContext(ctx, `SELECT `+promoCodeColumns+` FROM promo_codes WHERE id = $1`, id).Scan(
+		promoCodeFields(&code)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchPromoCode
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	report := model.PromoCodeReport{PromoCode: &code, Discounts: []model.DiscountAmount{}}
+	if code.MaxUses != nil {
+		remaining := *code.MaxUses - code.UsedCount
+		report.Remaining = &remaining
+	}
+
+	err = r.db.QueryRowContext(ctx, `SELECT
+		COUNT(*) FILTER (WHERE pr.status = $2),
+		COUNT(*) FILTER (WHERE pr.status = $2 AND b.status = $4),
+		COUNT(*) FILTER (WHERE pr.status = $3)
+	FROM promo_redemptions pr
+	JOIN bookings b ON b.id = pr.booking_id
+	WHERE pr.promo_code_id = $1`, id, RedemptionApplied, RedemptionReleased, StatusConfirmed).Scan(
+		&report.Applied, &report.Confirmed, &report.Released)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count promo code redemptions: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT currency, SUM(discount_minor)
+	FROM promo_redemptions
+	WHERE promo_code_id = $1 AND status = $2
+	GROUP BY currency
+	ORDER BY currency`, id, RedemptionApplied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum promo code discounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var amount model.DiscountAmount
+		if err = rows.Scan(&amount.Currency, &amount.AmountMinor); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		report.Discounts = append(report.Discounts, amount)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read promo code discounts: %w", err)
+	}
+
+	return &report, nil
+}
+
+// applyPromoCode takes one use of the code and lowers the price of the booking just inserted in tx.
+// The usage limit check and the increment are a single statement, so concurrent bookings
+// serialize on the code row and can never take more uses than the limit allows.
+func applyPromoCode(ctx context.Context, tx *sql.Tx, booking *model.Booking, code string) (*model.Booking, error) {
+	var promo model.PromoCode
+	err := tx.QueryRowContext(ctx, `SELECT `+promoCodeColumns+` FROM promo_codes WHERE code = $1`,
+		normalizePromoCode(code)).Scan(promoCodeFields(&promo)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchPromoCode
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	discount := promoDiscount(&promo, booking)
+	if discount == 0 {
+		return nil, ErrPromoCodeNotApplicable
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE promo_codes
+	SET used_count = used_count + 1,
+	    updated_at = NOW()
+	WHERE id = $1
+	  AND (max_uses IS NULL OR used_count < max_uses)
+	  AND (expires_at IS NULL OR expires_at > NOW())`, promo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use promo code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to use promo code: %w", err)
+	}
+	if rowsAffected == 0 {
+		var expired bool
+		err = tx.QueryRowContext(ctx, `SELECT COALESCE(expires_at <= NOW(), false) FROM promo_codes WHERE id = $1`,
+			promo.ID).Scan(&expired)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check promo code: %w", err)
+		}
+		if expired {
+			return nil, ErrPromoCodeExpired
+		}
+		return nil, ErrPromoCodeExhausted
+	}
+
+	discounted, err := scanBooking(tx.QueryRowContext(ctx, `UPDATE bookings
+	SET price_minor = price_minor - $2,
+	    discount_minor = $2,
+	    promo_code_id = $3,
+	    updated_at = NOW()
+	WHERE id = $1
+	RETURNING `+bookingColumns, booking.ID, discount, promo.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply discount: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO promo_redemptions(promo_code_id, booking_id, discount_minor, currency)
+	VALUES ($1, $2, $3, $4)`, promo.ID, booking.ID, discount, booking.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record promo code redemption: %w", err)
+	}
+
+	return discounted, nil
+}
+
+// promoDiscount returns how much the code takes off the booking price, or 0 if it does not apply.
+func promoDiscount(promo *model.PromoCode, booking *model.Booking) int64 {
+	if booking.PriceMinor == 0 {
+		return 0
+	}
+	if len(promo.EventIDs) > 0 && !slices.Contains(promo.EventIDs, booking.EventID) {
+		return 0
+	}
+	if len(promo.TicketTypeIDs) > 0 &&
+		(booking.TicketTypeID == nil || !slices.Contains(promo.TicketTypeIDs, *booking.TicketTypeID)) {
+		return 0
+	}
+
+	switch promo.DiscountType {
+	case PromoPercent:
+		return booking.PriceMinor * promo.DiscountValue / 100
+	case PromoFixed:
+		if promo.Currency != booking.Currency {
+			return 0
+		}
+		return min(promo.DiscountValue, booking.PriceMinor)
+	default:
+		return 0
+	}
+}
+
+// releasePromoCode gives the use of a code back when its booking ends without being paid.
+func releasePromoCode(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID) error {
+	query := `WITH released AS (
+		UPDATE promo_redemptions
+		SET status = $2,
+		    updated_at = NOW()
+		WHERE booking_id = $1 AND status = $3
+		RETURNING promo_code_id
+	)
+	UPDATE promo_codes
+	SET used_count = used_count - 1,
+	    updated_at = NOW()
+	WHERE id IN (SELECT promo_code_id FROM released)`
+
+	if _, err := tx.ExecContext(ctx, query, bookingID, RedemptionReleased, RedemptionApplied); err != nil {
+		return fmt.Errorf("failed to release promo code: %w", err)
+	}
+	return nil
+}
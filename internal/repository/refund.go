@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+)
+
+// FullRefundPercent is refunded when the organizer, not the attendee, is the reason the booking ends.
+const FullRefundPercent = 100
+
+// RefundReasonLatePayment is used when the money arrives after the booking has already expired or been cancelled.
+const RefundReasonLatePayment = "paid after the booking was released"
+
+const refundColumns = `r.id, r.booking_id, r.payment_id, p.provider, p.provider_payment_id, COALESCE(r.provider_refund_id, ''),
+	r.amount_minor, r.currency, r.percent, r.reason, r.status, r.attempts, COALESCE(r.last_error, ''),
+	r.refunded_at, r.created_at, r.updated_at`
+
+func refundFields(r *model.Refund) []any {
+	return []any{
+		&r.ID,
+		&r.BookingID,
+		&r.PaymentID,
+		&r.Provider,
+		&r.ProviderPaymentID,
+		&r.ProviderRefundID,
+		&r.AmountMinor,
+		&r.Currency,
+		&r.Percent,
+		&r.Reason,
+		&r.Status,
+		&r.Attempts,
+		&r.LastError,
+		&r.RefundedAt,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	}
+}
+
+// queueRefund records a refund of percent of the booking's successful payment inside tx.
+// The refunder sends it to the provider later, so the cancellation never waits for the provider.
+// Bookings that were never paid, and shares that round down to nothing, get no refund.
+func queueRefund(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID, percent int, reason string) error {
+	if percent <= 0 {
+		return nil
+	}
+	percent = min(percent, FullRefundPercent)
+
+	query := `INSERT INTO refunds(booking_id, payment_id, amount_minor, currency, percent, reason)
+	SELECT p.booking_id, p.id, p.amount_minor * $2 / 100, p.currency, $2, $3
+	FROM payments p
+	WHERE p.booking_id = $1
+	  AND p.status = $4
+	  AND p.amount_minor * $2 / 100 > 0
+	ON CONFLICT (payment_id) DO NOTHING`
+
+	if _, err := tx.ExecContext(ctx, query, bookingID, percent, reason, PaymentSucceeded); err != nil {
+		return fmt.Errorf("failed to queue refund: %w", err)
+	}
+	return nil
+}
+
+// GetPendingRefunds returns the oldest refunds that have not reached the provider yet.
+func (r *Postgres) GetPendingRefunds(ctx context.Context, limit int) ([]*model.Refund, error) {
+	query := `SELECT ` + refundColumns + `
+	FROM refunds r
+	JOIN payments p ON p.id = r.payment_id
+	WHERE r.status = $1
+	ORDER BY r.created_at
+	LIMIT $2`
+
+	return r.queryRefunds(ctx, query, RefundPending, limit)
+}
+
+func (r *Postgres) GetBookingRefunds(ctx context.Context, bookingID uuid.UUID) ([]*model.Refund, error) {
+	query := `SELECT ` + refundColumns + `
+	FROM refunds r
+	JOIN payments p ON p.id = r.payment_id
+	WHERE r.booking_id = $1
+	ORDER BY r.created_at`
+
+	return r.queryRefunds(ctx, query, bookingID)
+}
+
+func (r *Postgres) queryRefunds(ctx context.Context, query string, args ...any) ([]*model.Refund, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+	defer rows.Close()
+
+	refunds := []*model.Refund{}
+	for rows.Next() {
+		var refund model.Refund
+		if err = rows.Scan(refundFields(&refund)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		refunds = append(refunds, &refund)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read refunds: %w", err)
+	}
+
+	return refunds, nil
+}
+
+func (r *Postgres) MarkRefundSucceeded(ctx context.Context, id uuid.UUID, providerRefundID string) error {
+	query := `UPDATE refunds
+	SET status = $2,
+	    provider_refund_id = $3,
+	    attempts = attempts + 1,
+	    last_error = NULL,
+	    refunded_at = NOW(),
+	    updated_at = NOW()
+	WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, RefundSucceeded, providerRefundID); err != nil {
+		return fmt.Errorf("failed to mark refund succeeded: %w", err)
+	}
+	return nil
+}
+
+// MarkRefundFailed records a failed attempt. The refund stays pending for another
+// attempt until maxAttempts is reached, then it is failed for good.
+func (r *Postgres) MarkRefundFailed(ctx context.Context, id uuid.UUID, cause error, maxAttempts int) error {
+	query := `UPDATE refunds
+	SET attempts = attempts + 1,
+	    last_error = $2,
+	    status = CASE WHEN attempts + 1 >= $3 THEN $4 ELSE status END,
+	    updated_at = NOW()
+	WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, cause.Error(), maxAttempts, RefundFailed); err != nil {
+		return fmt.Errorf("failed to mark refund failed: %w", err)
+	}
+	return nil
+}
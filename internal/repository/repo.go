@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/zlog"
 )
@@ -12,19 +16,274 @@ import (
 var (
 	ErrNoSuchEvent                       = errors.New("there is no such event")
 	ErrNoSuchBooking                     = errors.New("there is no such booking")
+	ErrNoSuchWaitlistEntry               = errors.New("there is no such waitlist entry")
 	ErrEventNotFound                     = errors.New("event not found")
 	ErrEventsNotFound                    = errors.New("events not found")
-	ErrBookingNotFoundOrAlreadyConfirmed = errors.New("booking not found or already confirmed")
 	ErrBookingNotFoundOrAlreadyCancelled = errors.New("booking not found or already cancelled")
 	ErrNoSeatsAvailable                  = errors.New("no seats available")
+	ErrInvalidPlacesCount                = errors.New("places count must be positive")
+	ErrInvalidPaymentWindow              = errors.New("payment window must be positive")
+	ErrEmptyEventTitle                   = errors.New("event needs a title")
+	ErrInvalidTotalSeats                 = errors.New("total seats must be positive")
+	ErrEventVersionConflict              = errors.New("event was changed by someone else, reload it and retry")
+	ErrCapacityBelowBooked               = errors.New("total seats can not be less than seats already booked")
+	ErrEventHasBookings                  = errors.New("event has bookings, seat holds or a waitlist, cancel it instead")
+	ErrEventHasPendingRefunds            = errors.New("event has refunds that are not paid out yet")
+	ErrInvalidActor                      = errors.New("actor must be user or admin")
+	ErrIdempotencyKeyReused              = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress          = errors.New("a request with this idempotency key is still in progress")
+	ErrInvalidStatusTransition           = errors.New("invalid booking status transition")
+	ErrEventCancelled                    = errors.New("event is cancelled")
+	ErrEventNotOnSale                    = errors.New("event is not on sale")
+	ErrEventCompleted                    = errors.New("event is completed")
+	ErrEventNotFinished                  = errors.New("event has not taken place yet")
+	ErrInvalidEventStatus                = errors.New("event status must be draft or published")
+	ErrInvalidEventTransition            = errors.New("invalid event status transition")
+	ErrUnknownEventStatus                = errors.New("unknown event status")
+	ErrSalesNotOpen                      = errors.New("sales for the event have not opened yet")
+	ErrSalesClosed                       = errors.New("sales for the event are closed")
+	ErrInvalidSalesWindow                = errors.New("sales must open before they close and close no later than the event")
+	ErrNoSuchTicketType                  = errors.New("there is no such ticket type for the event")
+	ErrTicketTypeRequired                = errors.New("the event has ticket types, ticket_type_id is required")
+	ErrInvalidTicketType                 = errors.New("ticket type needs a name, a non-negative price, a 3-letter currency and a positive capacity")
+	ErrNoSuchPayment                     = errors.New("there is no such payment")
+	ErrPaymentRequired                   = errors.New("booking has a price and must be paid through the payment provider")
+	ErrUnknownPaymentStatus              = errors.New("payment status must be succeeded or failed")
+	ErrPaymentsDisabled                  = errors.New("no payment provider is configured")
+	ErrNothingToPay                      = errors.New("booking is free, confirm it instead")
+	ErrTicketTypeExists                  = errors.New("ticket type with this name already exists")
+	ErrTicketTypeCapacityExceeded        = errors.New("ticket type capacities exceed the event seats")
+	ErrCapacityBelowTicketTypes          = errors.New("total seats can not be less than the ticket type capacities")
+	ErrEventAlreadyCancelled             = errors.New("event is already cancelled")
+	ErrRescheduleRequired                = errors.New("event has active bookings, use reschedule to change its time")
+	ErrInvalidRescheduleTime             = errors.New("new event time must be in the future")
+	ErrInvalidOptOutDeadline             = errors.New("opt-out deadline must be in the future and not after the new event time")
+	ErrOptOutNotAvailable                = errors.New("booking can not be opted out: the event was not rescheduled after it was booked or the deadline has passed")
+	ErrInvalidPromoCode                  = errors.New("promo code needs a code, a percent discount of 1-100 or a positive fixed discount with a 3-letter currency, a positive usage limit and a future expiry")
+	ErrPromoCodeExists                   = errors.New("promo code already exists")
+	ErrNoSuchPromoCode                   = errors.New("there is no such promo code")
+	ErrPromoCodeExpired                  = errors.New("promo code has expired")
+	ErrPromoCodeExhausted                = errors.New("promo code usage limit is reached")
+	ErrPromoCodeNotApplicable            = errors.New("promo code does not apply to this event, ticket type or price")
+	ErrSeatsPerBookingExceeded           = errors.New("too many seats in one booking")
+	ErrSeatsPerUserExceeded              = errors.New("user would hold more seats of the event than allowed")
+	ErrPendingBookingsExceeded           = errors.New("user has too many unpaid bookings, pay or cancel one first")
+	ErrTelegramIDRequired                = errors.New("telegram_id is required")
+	ErrNoSuchHold                        = errors.New("there is no such seat hold")
+	ErrHoldNotActive                     = errors.New("seat hold was already booked or released")
+	ErrHoldExpired                       = errors.New("seat hold has expired")
+	ErrInvalidRecurrence                 = errors.New("recurrence needs a daily, weekly or monthly frequency, a positive interval, weekdays 0-6 and exactly one of until or count, and may not produce more than 366 occurrences")
+	ErrInvalidVenue                      = errors.New("venue needs a name, an IANA timezone such as Europe/Moscow and a positive capacity")
+	ErrNoSuchVenue                       = errors.New("there is no such venue")
+	ErrVenueInUse                        = errors.New("venue has events or a seat map and can not be deleted")
+	ErrInvalidPool                       = errors.New("capacity pool needs a name and a positive capacity")
+	ErrNoSuchPool                        = errors.New("there is no such capacity pool")
+	ErrPoolExhausted                     = errors.New("shared capacity pool has no seats left")
+	ErrPoolCapacityBelowUsed             = errors.New("pool capacity can not be less than the seats already taken from it")
+	ErrInvalidSeatMap                    = errors.New("seat map needs a name, exactly one of venue_id or event_id and named sections and rows of seats with distinct positive numbers")
+	ErrSeatMapExists                     = errors.New("the venue or event already has a seat map")
+	ErrNoSuchSeatMap                     = errors.New("there is no such seat map")
+	ErrNoSuchSeat                        = errors.New("seat is not on the seat map of the event")
+	ErrSeatTaken                         = errors.New("seat is already taken")
+	ErrSeatCountMismatch                 = errors.New("places_count must match the number of seat_ids")
+	ErrSeatSelectionRequired             = errors.New("the event has a seat map, seat_ids are required")
+	ErrSeatMapTooLarge                   = errors.New("seat map has more seats than the event's total_seats")
+	ErrSeatsTakenWithoutMap              = errors.New("the event already has seats taken without a seat map")
+	ErrSeatMapInUse                      = errors.New("the event has seats taken, it can not move to another seat map")
+	ErrInvalidSeries                     = errors.New("series needs a title and positive total seats")
+	ErrNoSuchSeries                      = errors.New("there is no such event series")
+	ErrInvalidSeriesScope                = errors.New("scope must be one, following or all; one and following need an occurrence_id of the series")
+	ErrOccurrenceHasBookings             = errors.New("occurrence has bookings, seat holds or a waitlist, change it through the event API")
 )
 
 const (
-	statusPending   = "pending"
+	StatusPending   = "pending"
 	StatusConfirmed = "confirmed"
-	statusCancelled = "cancelled"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
 )
 
+const (
+	EventDraft       = "draft"
+	EventPublished   = "published"
+	EventSalesClosed = "sales_closed"
+	EventCompleted   = "completed"
+	EventCancelled   = "cancelled"
+)
+
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+const (
+	ScopeOne       = "one"
+	ScopeFollowing = "following"
+	ScopeAll       = "all"
+)
+
+// MaxSeriesOccurrences keeps a single series from flooding the event list.
+const MaxSeriesOccurrences = 366
+
+const (
+	AllocationActive   = "active"
+	AllocationReleased = "released"
+)
+
+const (
+	HoldActive    = "active"
+	HoldConverted = "converted"
+	HoldExpired   = "expired"
+	HoldReleased  = "released"
+)
+
+const (
+	PromoPercent = "percent"
+	PromoFixed   = "fixed"
+)
+
+const (
+	RedemptionApplied  = "applied"
+	RedemptionReleased = "released"
+)
+
+// DefaultPaymentWindowMinutes is used for events created without an explicit payment window.
+const DefaultPaymentWindowMinutes = 15
+
+// bookingTransitions lists the statuses a booking may move to from its current status.
+// Cancelled and expired are terminal.
+var bookingTransitions = map[string][]string{
+	StatusPending:   {StatusConfirmed, StatusCancelled, StatusExpired},
+	StatusConfirmed: {StatusCancelled},
+}
+
+// CanTransition reports whether a booking in status from may be moved to status to.
+func CanTransition(from, to string) bool {
+	for _, next := range bookingTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionSources returns every status a booking may be moved to status to from.
+func transitionSources(to string) []string {
+	var sources []string
+	for from := range bookingTransitions {
+		if CanTransition(from, to) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+// eventTransitions lists the statuses an event may move to from its current status.
+// Only published events are on sale. Completed and cancelled are terminal.
+var eventTransitions = map[string][]string{
+	EventDraft:       {EventPublished, EventCancelled},
+	EventPublished:   {EventSalesClosed, EventCompleted, EventCancelled},
+	EventSalesClosed: {EventPublished, EventCompleted, EventCancelled},
+}
+
+// CanEventTransition reports whether an event in status from may be moved to status to.
+func CanEventTransition(from, to string) bool {
+	for _, next := range eventTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// eventStateError explains why an event in status can not be changed or booked.
+func eventStateError(status string) error {
+	switch status {
+	case EventCancelled:
+		return ErrEventCancelled
+	case EventCompleted:
+		return ErrEventCompleted
+	default:
+		return ErrEventNotOnSale
+	}
+}
+
+// validSalesWindow reports whether sales open before they close and close no later than the event starts.
+func validSalesWindow(opensAt, closesAt, eventAt time.Time) bool {
+	return opensAt.Before(closesAt) && !closesAt.After(eventAt)
+}
+
+// bookingColumns is the column list scanBooking expects, in order. The seats are the ones
+// the booking still holds on the event's seat map.
+const bookingColumns = `id, event_id, places_count, status, telegram_id, ticket_type_id, price_minor, COALESCE(currency, ''),
+	promo_code_id, discount_minor,
+	ARRAY(SELECT seat_id FROM seat_allocations WHERE booking_id = bookings.id AND status = '` + AllocationActive + `' ORDER BY seat_id),
+	expires_at, created_at, updated_at`
+
+func scanBooking(row *sql.Row) (*model.Booking, error) {
+	var booking model.Booking
+	err := row.Scan(
+		&booking.ID,
+		&booking.EventID,
+		&booking.PlacesCount,
+		&booking.Status,
+		&booking.TelegramID,
+		&booking.TicketTypeID,
+		&booking.PriceMinor,
+		&booking.Currency,
+		&booking.PromoCodeID,
+		&booking.DiscountMinor,
+		pq.Array(&booking.SeatIDs),
+		&booking.ExpiresAt,
+		&booking.CreatedAt,
+		&booking.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// eventColumns is the column list eventFields scans into, in order.
+const eventColumns = `id, title, total_seats, available_seats, payment_window_minutes, version, status, event_at, sale_opens_at, sale_closes_at, cancelled_at,
+	series_id, series_position, venue_id, pool_id, COALESCE((SELECT timezone FROM venues WHERE venues.id = venue_id), ''), created_at, updated_at`
+
+func eventFields(event *model.Event) []any {
+	return []any{
+		&event.ID,
+		&event.Title,
+		&event.TotalSeats,
+		&event.AvailableSeats,
+		&event.PaymentWindowMinutes,
+		&event.Version,
+		&event.Status,
+		&event.EventAt,
+		&event.SaleOpensAt,
+		&event.SaleClosesAt,
+		&event.CancelledAt,
+		&event.SeriesID,
+		&event.SeriesPosition,
+		&event.VenueID,
+		&event.PoolID,
+		&event.Timezone,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+	}
+}
+
 type Postgres struct {
 	db *dbpg.DB
 }
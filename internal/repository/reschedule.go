@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+// RescheduleEvent moves the event to a new time, records the old and the new time
+// and queues a notification with text for every attendee holding a pending or confirmed booking.
+func (r *Postgres) RescheduleEvent(ctx context.Context, eventID uuid.UUID, req *dto.RescheduleEvent, text string) (*model.RescheduleResult, error) {
+	if !req.EventAt.After(time.Now()) {
+		return nil, ErrInvalidRescheduleTime
+	}
+	if req.OptOutDeadline == nil || !req.OptOutDeadline.After(time.Now()) || req.OptOutDeadline.After(req.EventAt) {
+		return nil, ErrInvalidOptOutDeadline
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current model.Event
+	err = tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(
+		eventFields(&current)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+	if current.Status == EventCancelled || current.Status == EventCompleted {
+		return nil, eventStateError(current.Status)
+	}
+
+	// the sales cutoff moves together with the event, but never past its new start;
+	// an event moved before its sales open would have nothing left to sell
+	saleClosesAt := current.SaleClosesAt.Add(req.EventAt.Sub(current.EventAt))
+	if saleClosesAt.After(req.EventAt) {
+		saleClosesAt = req.EventAt
+	}
+	if !validSalesWindow(current.SaleOpensAt, saleClosesAt, req.EventAt) {
+		return nil, ErrInvalidSalesWindow
+	}
+
+	var reschedule model.EventReschedule
+	err = tx.QueryRowContext(ctx, `INSERT INTO event_reschedules(event_id, old_event_at, new_event_at, opt_out_deadline)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, event_id, old_event_at, new_event_at, opt_out_deadline, created_at`,
+		eventID, current.EventAt, req.EventAt, *req.OptOutDeadline).Scan(
+		&reschedule.ID,
+		&reschedule.EventID,
+		&reschedule.OldEventAt,
+		&reschedule.NewEventAt,
+		&reschedule.OptOutDeadline,
+		&reschedule.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record reschedule: %w", err)
+	}
+
+	var event model.Event
+	err = tx.QueryRowContext(ctx, `UPDATE events
+	SET event_at = $1,
+	    sale_closes_at = $3,
+	    version = version + 1,
+	    updated_at = NOW()
+	WHERE id = $2
+	RETURNING `+eventColumns, req.EventAt, eventID, saleClosesAt).Scan(eventFields(&event)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reschedule event: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT telegram_id
+	FROM bookings
+	WHERE event_id = $1 AND status IN ($2, $3) AND COALESCE(telegram_id, 0) <> 0`,
+		eventID, StatusPending, StatusConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event attendees: %w", err)
+	}
+
+	var recipients []int
+	for rows.Next() {
+		var telegramID int
+		if err = rows.Scan(&telegramID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		recipients = append(recipients, telegramID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event attendees: %w", err)
+	}
+
+	for _, telegramID := range recipients {
+		if err = enqueueNotification(ctx, tx, eventID, telegramID, text); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &model.RescheduleResult{
+		Event:               &event,
+		Reschedule:          &reschedule,
+		QueuedNotifications: len(recipients),
+	}, nil
+}
+
+// OptOutBooking cancels a booking and releases its seats because the event was moved.
+// It is allowed only for bookings made before a reschedule whose opt-out deadline has not passed yet;
+// bookings made after the reschedule already agreed to the new time.
+func (r *Postgres) OptOutBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	from, err := lockBookingStatus(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(from, StatusCancelled) {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	var allowed bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS(
+		SELECT 1
+		FROM bookings b
+		JOIN event_reschedules er ON er.event_id = b.event_id
+		WHERE b.id = $1 AND er.created_at > b.created_at AND er.opt_out_deadline > NOW()
+	)`, bookingID).Scan(&allowed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check reschedule: %w", err)
+	}
+	if !allowed {
+		return nil, ErrOptOutNotAvailable
+	}
+
+	booking, err := releaseLockedBooking(ctx, tx, bookingID, from, StatusCancelled, dto.StatusChange{
+		Actor:  ActorUser,
+		Reason: ReasonOptedOutOfReschedule,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// leaving because the organizer moved the event is refunded in full
+	if from == StatusConfirmed {
+		if err = queueRefund(ctx, tx, bookingID, FullRefundPercent, ReasonOptedOutOfReschedule); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return booking, nil
+}
@@ -0,0 +1,428 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const seatColumns = `id, section, seat_row, number, wheelchair, companion`
+
+func seatFields(s *model.Seat) []any {
+	return []any{
+		&s.ID,
+		&s.Section,
+		&s.Row,
+		&s.Number,
+		&s.Wheelchair,
+		&s.Companion,
+	}
+}
+
+// eventSeatMapID selects the seat map an event uses: its own one, or else the one of its venue.
+const eventSeatMapID = `COALESCE(
+	(SELECT id FROM seat_maps WHERE event_id = $1),
+	(SELECT sm.id FROM seat_maps sm JOIN events e ON e.venue_id = sm.venue_id WHERE e.id = $1))`
+
+// CreateSeatMap stores the layout with all its seats. Seats keep the order they are listed in.
+func (r *Postgres) CreateSeatMap(ctx context.Context, req *dto.CreateSeatMap) (*model.SeatMap, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || (req.VenueID == nil) == (req.EventID == nil) {
+		return nil, ErrInvalidSeatMap
+	}
+
+	type seatKey struct {
+		section, row string
+		number       int
+	}
+	seen := make(map[seatKey]struct{})
+	var sections, rows []string
+	var numbers, positions []int64
+	var wheelchairs, companions []bool
+	for _, section := range req.Sections {
+		sectionName := strings.TrimSpace(section.Name)
+		for _, row := range section.Rows {
+			rowName := strings.TrimSpace(row.Name)
+			for _, seat := range row.Seats {
+				key := seatKey{sectionName, rowName, seat.Number}
+				if _, ok := seen[key]; ok || sectionName == "" || rowName == "" || seat.Number <= 0 {
+					return nil, ErrInvalidSeatMap
+				}
+				seen[key] = struct{}{}
+
+				sections = append(sections, sectionName)
+				rows = append(rows, rowName)
+				numbers = append(numbers, int64(seat.Number))
+				wheelchairs = append(wheelchairs, seat.Wheelchair)
+				companions = append(companions, seat.Companion)
+				positions = append(positions, int64(len(positions)+1))
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return nil, ErrInvalidSeatMap
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = checkSeatMapSize(ctx, tx, req, len(positions)); err != nil {
+		return nil, err
+	}
+
+	seatMap := model.SeatMap{Seats: []model.Seat{}}
+	err = tx.QueryRowContext(ctx, `INSERT INTO seat_maps(venue_id, event_id, name)
+	VALUES ($1, $2, $3)
+	RETURNING id, venue_id, event_id, name, created_at`, req.VenueID, req.EventID, name).Scan(
+		&seatMap.ID, &seatMap.VenueID, &seatMap.EventID, &seatMap.Name, &seatMap.CreatedAt)
+	if err != nil {
+		var pgErr *pq.Error
+		switch {
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			return nil, ErrSeatMapExists
+		case isForeignKeyViolation(err, "seat_maps_venue_id_fkey"):
+			return nil, ErrNoSuchVenue
+		case isForeignKeyViolation(err, "seat_maps_event_id_fkey"):
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to create seat map: %w", err)
+	}
+
+	seatRows, err := tx.QueryContext(ctx, `INSERT INTO seats(seat_map_id, section, seat_row, number, wheelchair, companion, position)
+	SELECT $1, * FROM unnest($2::text[], $3::text[], $4::int[], $5::boolean[], $6::boolean[], $7::int[])
+	RETURNING `+seatColumns+`, position`,
+		seatMap.ID,
+		pq.Array(sections),
+		pq.Array(rows),
+		pq.Array(numbers),
+		pq.Array(wheelchairs),
+		pq.Array(companions),
+		pq.Array(positions),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seats: %w", err)
+	}
+
+	var seatPositions []int
+	for seatRows.Next() {
+		var seat model.Seat
+		var position int
+		if err = seatRows.Scan(append(seatFields(&seat), &position)...); err != nil {
+			seatRows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		seatMap.Seats = append(seatMap.Seats, seat)
+		seatPositions = append(seatPositions, position)
+	}
+	seatRows.Close()
+	if err = seatRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seats: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// RETURNING does not promise the input order
+	order := make([]model.Seat, len(seatMap.Seats))
+	for i, position := range seatPositions {
+		order[position-1] = seatMap.Seats[i]
+	}
+	seatMap.Seats = order
+
+	return &seatMap, nil
+}
+
+// checkSeatMapSize makes sure that every event the map is for can sell all of its seats and has not
+// given away places by count alone, which the map would then show as free. A venue map is also
+// checked against the venue capacity, the default size of its events. The events stay locked until tx ends.
+func checkSeatMapSize(ctx context.Context, tx *sql.Tx, req *dto.CreateSeatMap, seats int) error {
+	if req.VenueID != nil {
+		var capacity int
+		// the lock waits for events being added to or moved to the venue, and keeps new ones out until tx ends
+		err := tx.QueryRowContext(ctx, `SELECT capacity FROM venues WHERE id = $1 FOR UPDATE`, *req.VenueID).Scan(&capacity)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNoSuchVenue
+			}
+			return fmt.Errorf("failed to get venue: %w", err)
+		}
+		if seats > capacity {
+			return fmt.Errorf("%w: %d seats, venue capacity %d", ErrSeatMapTooLarge, seats, capacity)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT total_seats, available_seats
+	FROM events
+	WHERE (id = $1 OR (venue_id = $2 AND NOT EXISTS(SELECT 1 FROM seat_maps WHERE event_id = events.id)))
+	  AND status NOT IN ($3, $4)
+	ORDER BY id
+	FOR UPDATE`, req.EventID, req.VenueID, EventCancelled, EventCompleted)
+	if err != nil {
+		return fmt.Errorf("failed to lock events of the seat map: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total, available int
+		if err = rows.Scan(&total, &available); err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		if seats > total {
+			return fmt.Errorf("%w: %d seats, total_seats %d", ErrSeatMapTooLarge, seats, total)
+		}
+		if available < total {
+			return ErrSeatsTakenWithoutMap
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read events of the seat map: %w", err)
+	}
+	return nil
+}
+
+// eventSeatMap returns the seat map the event uses, nil if it has none.
+func eventSeatMap(ctx context.Context, q rowQuerier, eventID uuid.UUID) (*uuid.UUID, error) {
+	var mapID *uuid.UUID
+	if err := q.QueryRowContext(ctx, `SELECT `+eventSeatMapID, eventID).Scan(&mapID); err != nil {
+		return nil, fmt.Errorf("failed to get seat map of event: %w", err)
+	}
+	return mapID, nil
+}
+
+// checkEventSeatMap is the check of checkSeatMapSize for a single event whose size or venue was just
+// changed inside tx: the seat map it now uses must not have more seats than the event, and seats taken
+// on the map it used before, mapBefore, must not move to another one. It runs after the change, so
+// a seat map being created for the venue at the same time either waits for tx or is seen by it.
+func checkEventSeatMap(ctx context.Context, q rowQuerier, eventID uuid.UUID, mapBefore *uuid.UUID) error {
+	var mapID *uuid.UUID
+	var seats, total, available int
+	err := q.QueryRowContext(ctx, `SELECT m.id, (SELECT COUNT(*) FROM seats WHERE seat_map_id = m.id), e.total_seats, e.available_seats
+	FROM events e, (SELECT `+eventSeatMapID+` AS id) m
+	WHERE e.id = $1`, eventID).Scan(&mapID, &seats, &total, &available)
+	if err != nil {
+		return fmt.Errorf("failed to check seat map of event: %w", err)
+	}
+
+	if seats > total {
+		return fmt.Errorf("%w: %d seats, total_seats %d", ErrSeatMapTooLarge, seats, total)
+	}
+	sameMap := (mapID == nil && mapBefore == nil) || (mapID != nil && mapBefore != nil && *mapID == *mapBefore)
+	if !sameMap && available < total {
+		return ErrSeatMapInUse
+	}
+	return nil
+}
+
+func (r *Postgres) GetSeatMap(ctx context.Context, id uuid.UUID) (*model.SeatMap, error) {
+	seatMap := model.SeatMap{Seats: []model.Seat{}}
+	err := r.db.QueryRowContext(ctx, `SELECT id, venue_id, event_id, name, created_at FROM seat_maps WHERE id = $1`, id).Scan(
+		&seatMap.ID, &seatMap.VenueID, &seatMap.EventID, &seatMap.Name, &seatMap.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchSeatMap
+		}
+		return nil, fmt.Errorf("failed to get seat map: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+seatColumns+` FROM seats WHERE seat_map_id = $1 ORDER BY position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seat model.Seat
+		if err = rows.Scan(seatFields(&seat)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		seatMap.Seats = append(seatMap.Seats, seat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seats: %w", err)
+	}
+
+	return &seatMap, nil
+}
+
+// GetEventSeats returns the seats of the event's seat map and which of them are free.
+// Events without a seat map have no seats.
+func (r *Postgres) GetEventSeats(ctx context.Context, eventID uuid.UUID) ([]model.EventSeat, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+seatColumns+`,
+		NOT EXISTS(SELECT 1 FROM seat_allocations a WHERE a.event_id = $1 AND a.seat_id = seats.id AND a.status = $2)
+	FROM seats
+	WHERE seat_map_id = `+eventSeatMapID+`
+	ORDER BY position`, eventID, AllocationActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event seats: %w", err)
+	}
+	defer rows.Close()
+
+	var seats []model.EventSeat
+	for rows.Next() {
+		var seat model.EventSeat
+		if err = rows.Scan(append(seatFields(&seat.Seat), &seat.Available)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		seats = append(seats, seat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event seats: %w", err)
+	}
+
+	return seats, nil
+}
+
+// selectedSeats returns the distinct seats chosen for places seats. Choosing none is left
+// to checkSeatSelection, which knows whether the event has a seat map.
+func selectedSeats(places int, seatIDs []uuid.UUID) ([]uuid.UUID, error) {
+	unique := uniqueIDs(seatIDs)
+	if len(unique) > 0 && len(unique) != places {
+		return nil, ErrSeatCountMismatch
+	}
+	return unique, nil
+}
+
+// checkSeatSelection requires seats to be chosen whenever the event has a seat map, or else places
+// taken by count alone would still show as free on it, and every chosen seat to be on that map.
+func checkSeatSelection(ctx context.Context, q rowQuerier, eventID uuid.UUID, seatIDs []uuid.UUID) error {
+	var hasMap bool
+	var onMap int
+	err := q.QueryRowContext(ctx, `SELECT `+eventSeatMapID+` IS NOT NULL,
+		(SELECT COUNT(*) FROM seats WHERE id = ANY($2) AND seat_map_id = `+eventSeatMapID+`)`,
+		eventID, pq.Array(seatIDs)).Scan(&hasMap, &onMap)
+	if err != nil {
+		return fmt.Errorf("failed to check seat map: %w", err)
+	}
+
+	switch {
+	case hasMap && len(seatIDs) == 0:
+		return ErrSeatSelectionRequired
+	case onMap != len(seatIDs):
+		return ErrNoSuchSeat
+	}
+	return nil
+}
+
+// seatOwner is what seats are allocated to: a booking, or a seat hold until it becomes one.
+type seatOwner struct {
+	bookingID *uuid.UUID
+	holdID    *uuid.UUID
+}
+
+// allocateSeats gives the distinct seatIDs of the event's seat map to owner inside tx. Seats are taken
+// in id order, and the unique index on active allocations turns a second booking of a seat
+// into ErrSeatTaken, even if it raced past every other check.
+func allocateSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, owner seatOwner, seatIDs []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, `INSERT INTO seat_allocations(event_id, seat_id, booking_id, hold_id)
+	SELECT $1, id, $2, $3
+	FROM seats
+	WHERE id = ANY($4) AND seat_map_id = `+eventSeatMapID+`
+	ORDER BY id
+	RETURNING seat_id`, eventID, owner.bookingID, owner.holdID, pq.Array(seatIDs))
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrSeatTaken
+		}
+		return nil, fmt.Errorf("failed to allocate seats: %w", err)
+	}
+	defer rows.Close()
+
+	var allocated []uuid.UUID
+	for rows.Next() {
+		var seatID uuid.UUID
+		if err = rows.Scan(&seatID); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		allocated = append(allocated, seatID)
+	}
+
+	if err = rows.Err(); err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrSeatTaken
+		}
+		return nil, fmt.Errorf("failed to allocate seats: %w", err)
+	}
+
+	if len(allocated) != len(seatIDs) {
+		return nil, ErrNoSuchSeat
+	}
+	return allocated, nil
+}
+
+// releaseSeats frees the seats of a booking that no longer holds them.
+func releaseSeats(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE seat_allocations
+	SET status = $2,
+	    updated_at = NOW()
+	WHERE booking_id = $1 AND status = $3`, bookingID, AllocationReleased, AllocationActive)
+	if err != nil {
+		return fmt.Errorf("failed to release seats: %w", err)
+	}
+	return nil
+}
+
+// seatsFree reports whether none of seatIDs is allocated for the event.
+func seatsFree(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, seatIDs []uuid.UUID) (bool, error) {
+	var free bool
+	err := tx.QueryRowContext(ctx, `SELECT NOT EXISTS(SELECT 1
+	FROM seat_allocations
+	WHERE event_id = $1 AND seat_id = ANY($2) AND status = $3)`, eventID, pq.Array(seatIDs), AllocationActive).Scan(&free)
+	if err != nil {
+		return false, fmt.Errorf("failed to check seats: %w", err)
+	}
+	return free, nil
+}
+
+// moveHoldSeats hands the seats of a hold over to the booking it became.
+func moveHoldSeats(ctx context.Context, tx *sql.Tx, holdID, bookingID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, `UPDATE seat_allocations
+	SET booking_id = $2,
+	    updated_at = NOW()
+	WHERE hold_id = $1 AND status = $3
+	RETURNING seat_id`, holdID, bookingID, AllocationActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move held seats: %w", err)
+	}
+	defer rows.Close()
+
+	var moved []uuid.UUID
+	for rows.Next() {
+		var seatID uuid.UUID
+		if err = rows.Scan(&seatID); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		moved = append(moved, seatID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to move held seats: %w", err)
+	}
+	return moved, nil
+}
+
+// releaseHoldSeats frees the seats of a hold that ends without becoming a booking.
+func releaseHoldSeats(ctx context.Context, tx *sql.Tx, holdID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `UPDATE seat_allocations
+	SET status = $2,
+	    updated_at = NOW()
+	WHERE hold_id = $1 AND booking_id IS NULL AND status = $3`, holdID, AllocationReleased, AllocationActive)
+	if err != nil {
+		return fmt.Errorf("failed to release held seats: %w", err)
+	}
+	return nil
+}
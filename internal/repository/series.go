@@ -0,0 +1,432 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"slices"
+	"strings"
+	"time"
+)
+
+const seriesColumns = `id, title, total_seats, payment_window_minutes, frequency, interval, weekdays, starts_at, until, count,
+	created_at, updated_at`
+
+func seriesFields(s *model.EventSeries) []any {
+	return []any{
+		&s.ID,
+		&s.Title,
+		&s.TotalSeats,
+		&s.PaymentWindowMinutes,
+		&s.Frequency,
+		&s.Interval,
+		pq.Array(&s.Weekdays),
+		&s.StartsAt,
+		&s.Until,
+		&s.Count,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	}
+}
+
+// NormalizeRecurrence fills in the defaults of the rule and validates it.
+func NormalizeRecurrence(start time.Time, rule *dto.Recurrence) error {
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.Interval < 0 || (rule.Until == nil) == (rule.Count == nil) {
+		return ErrInvalidRecurrence
+	}
+	if rule.Count != nil && (*rule.Count <= 0 || *rule.Count > MaxSeriesOccurrences) {
+		return ErrInvalidRecurrence
+	}
+	if rule.Until != nil && rule.Until.Before(start) {
+		return ErrInvalidRecurrence
+	}
+
+	switch rule.Frequency {
+	case FrequencyWeekly:
+		if len(rule.Weekdays) == 0 {
+			rule.Weekdays = []int{int(start.Weekday())}
+		}
+		for _, day := range rule.Weekdays {
+			if day < 0 || day > 6 {
+				return ErrInvalidRecurrence
+			}
+		}
+		slices.Sort(rule.Weekdays)
+		rule.Weekdays = slices.Compact(rule.Weekdays)
+	case FrequencyDaily, FrequencyMonthly:
+		if len(rule.Weekdays) > 0 {
+			return ErrInvalidRecurrence
+		}
+	default:
+		return ErrInvalidRecurrence
+	}
+	return nil
+}
+
+// ExpandRecurrence returns the start times of the occurrences of a normalized rule, beginning with start.
+// Occurrences keep the wall clock time of start in its location, so a series does not drift across
+// daylight saving changes. Weekly series run in weeks starting on Sunday; monthly series skip
+// the months that do not have the day of start, as February does not have the 30th.
+func ExpandRecurrence(start time.Time, rule *dto.Recurrence) ([]time.Time, error) {
+	var occurrences []time.Time
+	// add reports whether the expansion should go on after t
+	add := func(t time.Time) (bool, error) {
+		if rule.Until != nil && t.After(*rule.Until) {
+			return false, nil
+		}
+		if len(occurrences) == MaxSeriesOccurrences {
+			return false, ErrInvalidRecurrence
+		}
+		occurrences = append(occurrences, t)
+		return rule.Count == nil || len(occurrences) < *rule.Count, nil
+	}
+
+	// every step moves at least a day forward, so the loops end long before this
+	const maxSteps = MaxSeriesOccurrences * 31
+
+	year, month, day := start.Date()
+	hour, minute, sec := start.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, sec, start.Nanosecond(), start.Location())
+	}
+
+	switch rule.Frequency {
+	case FrequencyDaily:
+		for step := 0; step < maxSteps; step++ {
+			more, err := add(at(year, month, day+step*rule.Interval))
+			if err != nil || !more {
+				return occurrences, err
+			}
+		}
+	case FrequencyWeekly:
+		weekStart := day - int(start.Weekday())
+		for step := 0; step < maxSteps; step++ {
+			for _, weekday := range rule.Weekdays {
+				t := at(year, month, weekStart+step*7*rule.Interval+weekday)
+				if t.Before(start) {
+					continue
+				}
+				more, err := add(t)
+				if err != nil || !more {
+					return occurrences, err
+				}
+			}
+		}
+	case FrequencyMonthly:
+		for step := 0; step < maxSteps; step++ {
+			t := at(year, month+time.Month(step*rule.Interval), day)
+			if t.Day() != day {
+				// the month is too short and time.Date rolled over into the next one
+				continue
+			}
+			more, err := add(t)
+			if err != nil || !more {
+				return occurrences, err
+			}
+		}
+	}
+	return nil, ErrInvalidRecurrence
+}
+
+// CreateEventSeries stores the series template and all its occurrences at once.
+// occurrences are the events of the series in order; they get the status and the payment window of the series.
+func (r *Postgres) CreateEventSeries(ctx context.Context, req *dto.CreateEventSeries, occurrences []*dto.CreateEvent) (*model.EventSeries, error) {
+	if strings.TrimSpace(req.Title) == "" || req.TotalSeats <= 0 || len(occurrences) == 0 {
+		return nil, ErrInvalidSeries
+	}
+	for _, occurrence := range occurrences {
+		occurrence.Status = req.Status
+		occurrence.PaymentWindowMinutes = req.PaymentWindowMinutes
+		if err := normalizeEvent(occurrence); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rule := req.Recurrence
+	var series model.EventSeries
+	err = tx.QueryRowContext(ctx, `INSERT INTO event_series(title, total_seats, payment_window_minutes, frequency, interval, weekdays,
+		starts_at, until, count)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING `+seriesColumns,
+		req.Title,
+		req.TotalSeats,
+		occurrences[0].PaymentWindowMinutes,
+		rule.Frequency,
+		rule.Interval,
+		pq.Array(rule.Weekdays),
+		req.StartsAt,
+		rule.Until,
+		rule.Count,
+	).Scan(seriesFields(&series)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event series: %w", err)
+	}
+
+	for i, occurrence := range occurrences {
+		created, err := insertEvent(ctx, tx, occurrence, &series.ID, i+1)
+		if err != nil {
+			return nil, err
+		}
+		series.Occurrences = append(series.Occurrences, created)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &series, nil
+}
+
+func (r *Postgres) GetEventSeries(ctx context.Context, id uuid.UUID) (*model.EventSeries, error) {
+	var series model.EventSeries
+	err := r.db.QueryRowContext(ctx, `SELECT `+seriesColumns+` FROM event_series WHERE id = $1`, id).Scan(
+		seriesFields(&series)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchSeries
+		}
+		return nil, fmt.Errorf("failed to get event series: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+eventColumns+` FROM events WHERE series_id = $1 ORDER BY series_position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event model.Event
+		if err = rows.Scan(eventFields(&event)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		series.Occurrences = append(series.Occurrences, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read series occurrences: %w", err)
+	}
+
+	return &series, nil
+}
+
+// UpdateEventSeries edits the occurrences picked by the scope in one transaction.
+// Occurrences somebody has booked, holds seats for or waits for, as well as cancelled and completed ones,
+// are protected: they are skipped and reported, and asking for such a single occurrence fails.
+func (r *Postgres) UpdateEventSeries(ctx context.Context, id uuid.UUID, upd *dto.UpdateEventSeries) (*model.SeriesUpdate, error) {
+	if upd.Title != nil && strings.TrimSpace(*upd.Title) == "" {
+		return nil, ErrInvalidSeries
+	}
+	if upd.TotalSeats != nil && *upd.TotalSeats <= 0 {
+		return nil, ErrInvalidSeries
+	}
+	if upd.PaymentWindowMinutes != nil && *upd.PaymentWindowMinutes <= 0 {
+		return nil, ErrInvalidPaymentWindow
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var series model.EventSeries
+	err = tx.QueryRowContext(ctx, `SELECT `+seriesColumns+` FROM event_series WHERE id = $1 FOR UPDATE`, id).Scan(
+		seriesFields(&series)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchSeries
+		}
+		return nil, fmt.Errorf("failed to lock event series: %w", err)
+	}
+
+	// from and to are the first and the last position in scope
+	from, to := 1, MaxSeriesOccurrences
+	switch upd.Scope {
+	case ScopeOne, ScopeFollowing:
+		if upd.OccurrenceID == nil {
+			return nil, ErrInvalidSeriesScope
+		}
+		err = tx.QueryRowContext(ctx, `SELECT series_position FROM events WHERE id = $1 AND series_id = $2`,
+			*upd.OccurrenceID, id).Scan(&from)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrInvalidSeriesScope
+			}
+			return nil, fmt.Errorf("failed to get occurrence: %w", err)
+		}
+		if upd.Scope == ScopeOne {
+			to = from
+		}
+	case ScopeAll:
+	default:
+		return nil, ErrInvalidSeriesScope
+	}
+
+	// the event locks keep new bookings and holds out until the edit is done
+	rows, err := tx.QueryContext(ctx, `SELECT `+eventColumns+`,
+		EXISTS(SELECT 1 FROM bookings b WHERE b.event_id = events.id AND b.status IN ($4, $5))
+		OR EXISTS(SELECT 1 FROM seat_holds h WHERE h.event_id = events.id AND h.status = $6)
+		OR EXISTS(SELECT 1 FROM waitlist w WHERE w.event_id = events.id AND w.status = $7)
+	FROM events
+	WHERE series_id = $1 AND series_position BETWEEN $2 AND $3
+	ORDER BY series_position
+	FOR UPDATE`, id, from, to, StatusPending, StatusConfirmed, HoldActive, WaitlistWaiting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock series occurrences: %w", err)
+	}
+
+	var occurrences []*model.Event
+	var booked []bool
+	for rows.Next() {
+		var event model.Event
+		var taken bool
+		if err = rows.Scan(append(eventFields(&event), &taken)...); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		occurrences = append(occurrences, &event)
+		booked = append(booked, taken)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read series occurrences: %w", err)
+	}
+
+	result := &model.SeriesUpdate{Series: &series, Updated: []*model.Event{}, Skipped: []uuid.UUID{}}
+	for i, occurrence := range occurrences {
+		if booked[i] || occurrence.Status == EventCancelled || occurrence.Status == EventCompleted {
+			if upd.Scope == ScopeOne {
+				if booked[i] {
+					return nil, ErrOccurrenceHasBookings
+				}
+				return nil, eventStateError(occurrence.Status)
+			}
+			result.Skipped = append(result.Skipped, occurrence.ID)
+			continue
+		}
+
+		updated, err := updateOccurrence(ctx, tx, occurrence, upd)
+		if err != nil {
+			return nil, err
+		}
+		result.Updated = append(result.Updated, updated)
+	}
+
+	if upd.Scope == ScopeAll {
+		if err = updateSeriesTemplate(ctx, tx, &series, upd); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// updateOccurrence applies the series edit to an occurrence nobody has booked,
+// so all its seats are free apart from the ones promised to ticket types.
+func updateOccurrence(ctx context.Context, tx *sql.Tx, event *model.Event, upd *dto.UpdateEventSeries) (*model.Event, error) {
+	title, paymentWindow, totalSeats := event.Title, event.PaymentWindowMinutes, event.TotalSeats
+	eventAt, saleClosesAt := event.EventAt, event.SaleClosesAt
+	if upd.Title != nil {
+		title = *upd.Title
+	}
+	if upd.PaymentWindowMinutes != nil {
+		paymentWindow = *upd.PaymentWindowMinutes
+	}
+	if upd.ShiftMinutes != nil {
+		// the sales cutoff moves together with the occurrence
+		shift := time.Duration(*upd.ShiftMinutes) * time.Minute
+		eventAt, saleClosesAt = eventAt.Add(shift), saleClosesAt.Add(shift)
+		if !validSalesWindow(event.SaleOpensAt, saleClosesAt, eventAt) {
+			return nil, ErrInvalidSalesWindow
+		}
+	}
+	if upd.TotalSeats != nil {
+		committed, err := committedSeats(ctx, tx, event.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+		if *upd.TotalSeats < committed {
+			return nil, ErrCapacityBelowTicketTypes
+		}
+		totalSeats = *upd.TotalSeats
+	}
+
+	var updated model.Event
+	err := tx.QueryRowContext(ctx, `UPDATE events
+	SET title = $1,
+	    event_at = $2,
+	    payment_window_minutes = $3,
+	    total_seats = $4,
+	    available_seats = $4,
+	    sale_closes_at = $5,
+	    version = version + 1,
+	    updated_at = NOW()
+	WHERE id = $6
+	RETURNING `+eventColumns, title, eventAt, paymentWindow, totalSeats, saleClosesAt, event.ID).Scan(
+		eventFields(&updated)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update occurrence: %w", err)
+	}
+
+	if upd.TotalSeats != nil {
+		if err = checkEventSeatMap(ctx, tx, event.ID, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &updated, nil
+}
+
+// updateSeriesTemplate keeps the series in line with an edit of all its occurrences.
+func updateSeriesTemplate(ctx context.Context, tx *sql.Tx, series *model.EventSeries, upd *dto.UpdateEventSeries) error {
+	if upd.Title != nil {
+		series.Title = *upd.Title
+	}
+	if upd.TotalSeats != nil {
+		series.TotalSeats = *upd.TotalSeats
+	}
+	if upd.PaymentWindowMinutes != nil {
+		series.PaymentWindowMinutes = *upd.PaymentWindowMinutes
+	}
+	if upd.ShiftMinutes != nil {
+		shift := time.Duration(*upd.ShiftMinutes) * time.Minute
+		series.StartsAt = series.StartsAt.Add(shift)
+		if series.Until != nil {
+			until := series.Until.Add(shift)
+			series.Until = &until
+		}
+	}
+
+	err := tx.QueryRowContext(ctx, `UPDATE event_series
+	SET title = $2,
+	    total_seats = $3,
+	    payment_window_minutes = $4,
+	    starts_at = $5,
+	    until = $6,
+	    updated_at = NOW()
+	WHERE id = $1
+	RETURNING `+seriesColumns,
+		series.ID, series.Title, series.TotalSeats, series.PaymentWindowMinutes, series.StartsAt, series.Until).Scan(
+		seriesFields(series)...)
+	if err != nil {
+		return fmt.Errorf("failed to update event series: %w", err)
+	}
+	return nil
+}
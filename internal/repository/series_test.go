@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/K1la/event-booker/internal/dto"
+)
+
+func TestExpandRecurrence(t *testing.T) {
+	count := func(n int) *int { return &n }
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 19, 0, 0, 0, time.UTC)
+	}
+	until := date(time.February, 1)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		rule  dto.Recurrence
+		want  []time.Time
+	}{
+		{
+			name:  "daily every other day",
+			start: date(time.January, 1),
+			rule:  dto.Recurrence{Frequency: FrequencyDaily, Interval: 2, Count: count(3)},
+			want:  []time.Time{date(time.January, 1), date(time.January, 3), date(time.January, 5)},
+		},
+		{
+			// January 1st 2026 is a Thursday
+			name:  "weekly on tuesdays and thursdays",
+			start: date(time.January, 1),
+			rule:  dto.Recurrence{Frequency: FrequencyWeekly, Weekdays: []int{4, 2}, Count: count(4)},
+			want:  []time.Time{date(time.January, 1), date(time.January, 6), date(time.January, 8), date(time.January, 13)},
+		},
+		{
+			name:  "weekly until a date",
+			start: date(time.January, 8),
+			rule:  dto.Recurrence{Frequency: FrequencyWeekly, Interval: 2, Until: &until},
+			want:  []time.Time{date(time.January, 8), date(time.January, 22)},
+		},
+		{
+			name:  "monthly skips short months",
+			start: date(time.January, 31),
+			rule:  dto.Recurrence{Frequency: FrequencyMonthly, Count: count(3)},
+			want:  []time.Time{date(time.January, 31), date(time.March, 31), date(time.May, 31)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NormalizeRecurrence(tt.start, &tt.rule); err != nil {
+				t.Fatalf("rule is invalid: %v", err)
+			}
+			got, err := ExpandRecurrence(tt.start, &tt.rule)
+			if err != nil {
+				t.Fatalf("expand failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d occurrences %v, want %v", len(got), got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("occurrence %d is %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExpandRecurrenceLimits(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 10, 0, 0, 0, time.UTC)
+	farAway := start.AddDate(5, 0, 0)
+
+	rule := dto.Recurrence{Frequency: FrequencyDaily, Until: &farAway}
+	if err := NormalizeRecurrence(start, &rule); err != nil {
+		t.Fatalf("rule is invalid: %v", err)
+	}
+	if _, err := ExpandRecurrence(start, &rule); !errors.Is(err, ErrInvalidRecurrence) {
+		t.Errorf("expected %v for too many occurrences, got %v", ErrInvalidRecurrence, err)
+	}
+
+	both := dto.Recurrence{Frequency: FrequencyDaily, Until: &farAway, Count: new(int)}
+	if err := NormalizeRecurrence(start, &both); !errors.Is(err, ErrInvalidRecurrence) {
+		t.Errorf("expected %v for until together with count, got %v", ErrInvalidRecurrence, err)
+	}
+}
+
+func TestExpandRecurrenceAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+
+	// the client sends 19:00 in winter time; clocks in Berlin go forward on March 29th 2026
+	start := time.Date(2026, time.March, 26, 19, 0, 0, 0, time.FixedZone("", 60*60)).In(berlin)
+	count := 2
+	rule := dto.Recurrence{Frequency: FrequencyWeekly, Count: &count}
+	if err = NormalizeRecurrence(start, &rule); err != nil {
+		t.Fatalf("rule is invalid: %v", err)
+	}
+
+	got, err := ExpandRecurrence(start, &rule)
+	if err != nil {
+		t.Fatalf("expand failed: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2026, time.March, 26, 18, 0, 0, 0, time.UTC),
+		time.Date(2026, time.April, 2, 17, 0, 0, 0, time.UTC),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences %v, want %v", len(got), got, want)
+	}
+	for i := range got {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d is %v, want %v", i, got[i].UTC(), want[i])
+		}
+		if hour := got[i].In(berlin).Hour(); hour != 19 {
+			t.Errorf("occurrence %d starts at %d:00 in Berlin, want 19:00", i, hour)
+		}
+	}
+}
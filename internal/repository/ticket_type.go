@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const ticketTypeColumns = `id, event_id, name, price_minor, currency, capacity, available, pool_id, created_at, updated_at`
+
+func ticketTypeFields(tt *model.TicketType) []any {
+	return []any{
+		&tt.ID,
+		&tt.EventID,
+		&tt.Name,
+		&tt.PriceMinor,
+		&tt.Currency,
+		&tt.Capacity,
+		&tt.Available,
+		&tt.PoolID,
+		&tt.CreatedAt,
+		&tt.UpdatedAt,
+	}
+}
+
+// CreateTicketType adds a ticket type to the event. The capacities of all types together with
+// the seats booked without a type must fit into the event seats.
+func (r *Postgres) CreateTicketType(ctx context.Context, eventID uuid.UUID, req *dto.CreateTicketType) (*model.TicketType, error) {
+	name := strings.TrimSpace(req.Name)
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if name == "" || req.PriceMinor < 0 || len(currency) != 3 || req.Capacity <= 0 {
+		return nil, ErrInvalidTicketType
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the event lock keeps bookings out while the capacities are checked
+	var event model.Event
+	err = tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(
+		eventFields(&event)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+	if event.Status == EventCancelled || event.Status == EventCompleted {
+		return nil, eventStateError(event.Status)
+	}
+
+	committed, err := committedSeats(ctx, tx, eventID, event.TotalSeats-event.AvailableSeats)
+	if err != nil {
+		return nil, err
+	}
+	if committed+req.Capacity > event.TotalSeats {
+		return nil, ErrTicketTypeCapacityExceeded
+	}
+
+	query := `INSERT INTO ticket_types(event_id, name, price_minor, currency, capacity, available, pool_id)
+	VALUES ($1, $2, $3, $4, $5, $5, $6)
+	RETURNING ` + ticketTypeColumns
+
+	var created model.TicketType
+	err = tx.QueryRowContext(ctx, query, eventID, name, req.PriceMinor, currency, req.Capacity, req.PoolID).Scan(
+		ticketTypeFields(&created)...)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrTicketTypeExists
+		}
+		if isForeignKeyViolation(err, "ticket_types_pool_id_fkey") {
+			return nil, ErrNoSuchPool
+		}
+		return nil, fmt.Errorf("failed to create ticket type: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *Postgres) GetTicketTypes(ctx context.Context, eventID uuid.UUID) ([]model.TicketType, error) {
+	query := `SELECT ` + ticketTypeColumns + `
+	FROM ticket_types
+	WHERE event_id = $1
+	ORDER BY price_minor, name`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket types: %w", err)
+	}
+	defer rows.Close()
+
+	ticketTypes := []model.TicketType{}
+	for rows.Next() {
+		var tt model.TicketType
+		if err = rows.Scan(ticketTypeFields(&tt)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		ticketTypes = append(ticketTypes, tt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ticket types: %w", err)
+	}
+
+	return ticketTypes, nil
+}
+
+// committedSeats returns how many event seats are spoken for: the capacities of the ticket types
+// plus the seats booked without a type. booked is the number of seats held by all bookings.
+func committedSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, booked int) (int, error) {
+	var capacity, typedBooked int
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(capacity), 0), COALESCE(SUM(capacity - available), 0)
+	FROM ticket_types WHERE event_id = $1`, eventID).Scan(&capacity, &typedBooked)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum ticket type capacities: %w", err)
+	}
+	return capacity + booked - typedBooked, nil
+}
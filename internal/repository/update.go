@@ -6,63 +6,482 @@ import (
 	"errors"
 	"fmt"
 	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
 	"github.com/google/uuid"
 )
 
-func (r *Postgres) ConfirmBookingPayment(ctx context.Context, eventID uuid.UUID) error {
-	query := `UPDATE bookings
-	SET status = $1,
-	    updated_at = NOW()
-	WHERE event_id = $2`
+// UpdateEvent applies the changes if the caller saw the current version of the event.
+// Resizing keeps the seats already held by bookings: available seats move by the same delta as total seats.
+func (r *Postgres) UpdateEvent(ctx context.Context, eventID uuid.UUID, upd *dto.UpdateEvent) (*model.Event, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	err := r.db.QueryRowContext(ctx, query, StatusConfirmed, eventID).Scan()
+	var current model.Event
+	err = tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(
+		eventFields(&current)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return ErrBookingNotFoundOrAlreadyConfirmed
+			return nil, ErrEventNotFound
 		}
-		return fmt.Errorf("failed to confirm booking payment: %w", err)
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	if current.Status == EventCancelled || current.Status == EventCompleted {
+		return nil, eventStateError(current.Status)
 	}
 
-	return nil
+	if current.Version != upd.Version {
+		return nil, ErrEventVersionConflict
+	}
+
+	title, eventAt, paymentWindow := current.Title, current.EventAt, current.PaymentWindowMinutes
+	totalSeats, availableSeats := current.TotalSeats, current.AvailableSeats
+	if upd.Title != nil {
+		title = *upd.Title
+	}
+	saleOpensAt, saleClosesAt := current.SaleOpensAt, current.SaleClosesAt
+	venueID := current.VenueID
+	if upd.VenueID != nil {
+		venueID = upd.VenueID
+	}
+	if upd.EventAt != nil {
+		// attendees must be told about a new time and given a chance to leave
+		if !upd.EventAt.Equal(current.EventAt) && current.AvailableSeats < current.TotalSeats {
+			return nil, ErrRescheduleRequired
+		}
+		eventAt = *upd.EventAt
+		// the sales cutoff moves together with the event
+		saleClosesAt = saleClosesAt.Add(eventAt.Sub(current.EventAt))
+	}
+	if upd.SaleOpensAt != nil {
+		saleOpensAt = *upd.SaleOpensAt
+	}
+	if upd.SaleClosesAt != nil {
+		saleClosesAt = *upd.SaleClosesAt
+	}
+	if !validSalesWindow(saleOpensAt, saleClosesAt, eventAt) {
+		return nil, ErrInvalidSalesWindow
+	}
+	if upd.PaymentWindowMinutes != nil {
+		if *upd.PaymentWindowMinutes <= 0 {
+			return nil, ErrInvalidPaymentWindow
+		}
+		paymentWindow = *upd.PaymentWindowMinutes
+	}
+	if upd.TotalSeats != nil {
+		booked := current.TotalSeats - current.AvailableSeats
+		if *upd.TotalSeats < booked {
+			return nil, ErrCapacityBelowBooked
+		}
+		committed, err := committedSeats(ctx, tx, eventID, booked)
+		if err != nil {
+			return nil, err
+		}
+		if *upd.TotalSeats < committed {
+			return nil, ErrCapacityBelowTicketTypes
+		}
+		totalSeats = *upd.TotalSeats
+		availableSeats = totalSeats - booked
+	}
+
+	query := `UPDATE events
+	SET title = $1,
+	    event_at = $2,
+	    payment_window_minutes = $3,
+	    total_seats = $4,
+	    available_seats = $5,
+	    sale_opens_at = $6,
+	    sale_closes_at = $7,
+	    venue_id = $9,
+	    version = version + 1,
+	    updated_at = NOW()
+	WHERE id = $8
+	RETURNING ` + eventColumns
+
+	mapBefore, err := eventSeatMap(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated model.Event
+	err = tx.QueryRowContext(ctx, query, title, eventAt, paymentWindow, totalSeats, availableSeats, saleOpensAt, saleClosesAt, eventID, venueID).Scan(
+		eventFields(&updated)...)
+	if err != nil {
+		if isForeignKeyViolation(err, "events_venue_id_fkey") {
+			return nil, ErrNoSuchVenue
+		}
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+
+	if upd.TotalSeats != nil || upd.VenueID != nil {
+		if err = checkEventSeatMap(ctx, tx, eventID, mapBefore); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &updated, nil
 }
-func (r *Postgres) CancelBooking(ctx context.Context, booking *dto.QueueMessage) error {
+
+func (r *Postgres) ConfirmBooking(ctx context.Context, bookingID uuid.UUID, change dto.StatusChange) (*model.Booking, error) {
 	tx, err := r.db.Master.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	cancelBookingQuery := `
-		UPDATE bookings
-		SET status = $1,
-		    updated_at = NOW()
-		WHERE id = $2
-		RETURNING event_id;
-    `
+	from, err := lockBookingStatus(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(from, StatusConfirmed) {
+		// the booking left pending between the service check and the update
+		return nil, ErrInvalidStatusTransition
+	}
 
-	var eventID uuid.UUID
-	err = tx.QueryRowContext(ctx, cancelBookingQuery, statusCancelled, booking.BookingID).Scan(&eventID)
+	booking, err := setBookingStatus(ctx, tx, bookingID, from, StatusConfirmed, change)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrBookingNotFoundOrAlreadyCancelled
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return booking, nil
+}
+
+// CancelBooking cancels the booking and, if it was confirmed when its row got locked, queues a refund
+// of refundPercent of its payment in the same transaction.
+func (r *Postgres) CancelBooking(ctx context.Context, bookingID uuid.UUID, change dto.StatusChange, refundPercent int) (*model.Booking, error) {
+	return r.releaseBooking(ctx, bookingID, StatusCancelled, change, refundPercent)
+}
+
+func (r *Postgres) ExpireBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error) {
+	return r.releaseBooking(ctx, bookingID, StatusExpired, dto.StatusChange{
+		Actor:  ActorSystem,
+		Reason: ReasonPaymentDeadlinePassed,
+	}, 0)
+}
+
+// releaseBooking moves a booking to the terminal status and returns its seats to the event.
+// The number of seats is always taken from the booking row itself.
+func (r *Postgres) releaseBooking(ctx context.Context, bookingID uuid.UUID, status string, change dto.StatusChange, refundPercent int) (*model.Booking, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the row lock makes the expiry worker and the user race for the booking:
+	// whoever comes second sees the terminal status, so the seats are returned only once
+	from, err := lockBookingStatus(ctx, tx, bookingID)
+	if err != nil {
+		if errors.Is(err, ErrNoSuchBooking) {
+			return nil, ErrBookingNotFoundOrAlreadyCancelled
+		}
+		return nil, err
+	}
+	if !CanTransition(from, status) {
+		return nil, ErrBookingNotFoundOrAlreadyCancelled
+	}
+
+	booking, err := releaseLockedBooking(ctx, tx, bookingID, from, status, change)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == StatusConfirmed {
+		if err = queueRefund(ctx, tx, bookingID, refundPercent, change.Reason); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return booking, nil
+}
+
+// releaseLockedBooking moves a booking locked by lockBookingStatus to the terminal status
+// and returns its seats to the event inside tx.
+func releaseLockedBooking(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID, from, status string, change dto.StatusChange) (*model.Booking, error) {
+	booking, err := setBookingStatus(ctx, tx, bookingID, from, status, change)
+	if err != nil {
+		return nil, err
+	}
+
+	// a code used by a booking that was never paid can be used again
+	if from == StatusPending {
+		if err = releasePromoCode(ctx, tx, bookingID); err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("failed to cancel booking: %w", err)
 	}
 
+	if err = returnSeats(ctx, tx, booking.EventID, booking.TicketTypeID, booking.PlacesCount); err != nil {
+		return nil, err
+	}
+
+	if err = releaseSeats(ctx, tx, bookingID); err != nil {
+		return nil, err
+	}
+	booking.SeatIDs = nil
+
+	return booking, nil
+}
+
+// returnSeats gives places seats back to the event, and to the ticket type if one is given, inside tx.
+func returnSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID, ticketTypeID *uuid.UUID, places int) error {
 	updateEventQuery := `
- 		UPDATE events
+		UPDATE events
 		SET available_seats = available_seats + $1,
 		    updated_at = NOW()
- 		WHERE id = $2;
+		WHERE id = $2;
 	`
-	_, err = tx.ExecContext(ctx, updateEventQuery, booking.PlacesCount, eventID)
+	_, err := tx.ExecContext(ctx, updateEventQuery, places, eventID)
 	if err != nil {
 		return fmt.Errorf("failed to update event seats: %w", err)
 	}
 
+	if ticketTypeID != nil {
+		_, err = tx.ExecContext(ctx, `UPDATE ticket_types
+		SET available = available + $1,
+		    updated_at = NOW()
+		WHERE id = $2`, places, *ticketTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to update ticket type seats: %w", err)
+		}
+	}
+
+	return returnPoolSeats(ctx, tx, eventID, ticketTypeID, places)
+}
+
+// lockBookingStatus locks the booking row until the end of tx and returns its current status.
+// The booking's event is locked first: every transaction that locks both takes the event
+// before its bookings and seat holds, as booking and cancelling the event do, so none of them
+// can wait for another in the opposite order.
+func lockBookingStatus(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID) (string, error) {
+	_, err := tx.ExecContext(ctx, `SELECT 1 FROM events WHERE id = (SELECT event_id FROM bookings WHERE id = $1) FOR UPDATE`,
+		bookingID)
+	if err != nil {
+		return "", fmt.Errorf("failed to lock event of booking: %w", err)
+	}
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM bookings WHERE id = $1 FOR UPDATE`, bookingID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNoSuchBooking
+		}
+		return "", fmt.Errorf("failed to lock booking: %w", err)
+	}
+	return status, nil
+}
+
+// setBookingStatus updates a locked booking and appends the transition to its history.
+func setBookingStatus(ctx context.Context, tx *sql.Tx, bookingID uuid.UUID, from, to string, change dto.StatusChange) (*model.Booking, error) {
+	query := `UPDATE bookings
+	SET status = $1,
+	    updated_at = NOW()
+	WHERE id = $2
+	RETURNING ` + bookingColumns
+
+	booking, err := scanBooking(tx.QueryRowContext(ctx, query, to, bookingID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set booking status %s: %w", to, err)
+	}
+
+	if err = recordHistory(ctx, tx, bookingID, from, to, change); err != nil {
+		return nil, err
+	}
+
+	return booking, nil
+}
+
+// CancelEvent calls the event off: every pending or confirmed booking is cancelled by the admin
+// and the waitlist is closed. Booking holders are notified with bookingText, users who were only
+// waiting with waitlistText. Everything happens in one transaction, so attendees are notified
+// only if the cancellation committed.
+func (r *Postgres) CancelEvent(ctx context.Context, eventID uuid.UUID, bookingText, waitlistText string) (*model.EventCancellation, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the event lock keeps new bookings and waitlist promotions out while the event is cancelled;
+	// it is taken before the bookings and holds, like everywhere else (see lockBookingStatus)
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+	if status == EventCancelled {
+		return nil, ErrEventAlreadyCancelled
+	}
+	if !CanEventTransition(status, EventCancelled) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidEventTransition, status, EventCancelled)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, status, COALESCE(telegram_id, 0), ticket_type_id, places_count
+	FROM bookings
+	WHERE event_id = $1 AND status IN ($2, $3)
+	ORDER BY created_at
+	FOR UPDATE`, eventID, StatusPending, StatusConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event bookings: %w", err)
+	}
+
+	var bookings []model.Booking
+	for rows.Next() {
+		var b model.Booking
+		if err = rows.Scan(&b.ID, &b.Status, &b.TelegramID, &b.TicketTypeID, &b.PlacesCount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		bookings = append(bookings, b)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event bookings: %w", err)
+	}
+
+	change := dto.StatusChange{Actor: ActorAdmin, Reason: ReasonEventCancelled}
+	recipients := make(map[int]string)
+	for _, b := range bookings {
+		if _, err = setBookingStatus(ctx, tx, b.ID, b.Status, StatusCancelled, change); err != nil {
+			return nil, err
+		}
+		// the event counters are reset below, but shared pools serve other events too
+		if err = returnPoolSeats(ctx, tx, eventID, b.TicketTypeID, b.PlacesCount); err != nil {
+			return nil, err
+		}
+		// the attendee did not choose to leave, so paid bookings get all their money back
+		// and unpaid ones give their promo code use back
+		if b.Status == StatusConfirmed {
+			if err = queueRefund(ctx, tx, b.ID, FullRefundPercent, ReasonEventCancelled); err != nil {
+				return nil, err
+			}
+		} else if err = releasePromoCode(ctx, tx, b.ID); err != nil {
+			return nil, err
+		}
+		if b.TelegramID != 0 {
+			recipients[b.TelegramID] = bookingText
+		}
+	}
+
+	waitlistRows, err := tx.QueryContext(ctx, `UPDATE waitlist
+	SET status = $1,
+	    updated_at = NOW()
+	WHERE event_id = $2 AND status = $3
+	RETURNING telegram_id`, WaitlistCancelled, eventID, WaitlistWaiting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close waitlist: %w", err)
+	}
+
+	var waitlisted int
+	for waitlistRows.Next() {
+		var telegramID int
+		if err = waitlistRows.Scan(&telegramID); err != nil {
+			waitlistRows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		waitlisted++
+		// a user who also holds a booking hears about the booking
+		if _, ok := recipients[telegramID]; !ok && telegramID != 0 {
+			recipients[telegramID] = waitlistText
+		}
+	}
+	waitlistRows.Close()
+	if err = waitlistRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read waitlist: %w", err)
+	}
+
+	for telegramID, text := range recipients {
+		if err = enqueueNotification(ctx, tx, eventID, telegramID, text); err != nil {
+			return nil, err
+		}
+	}
+
+	// their seats come back with the counters reset below
+	holdRows, err := tx.QueryContext(ctx, `UPDATE seat_holds
+	SET status = $1,
+	    updated_at = NOW()
+	WHERE event_id = $2 AND status = $3
+	RETURNING ticket_type_id, places_count`, HoldReleased, eventID, HoldActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release seat holds: %w", err)
+	}
+
+	var holds []model.SeatHold
+	for holdRows.Next() {
+		var hold model.SeatHold
+		if err = holdRows.Scan(&hold.TicketTypeID, &hold.PlacesCount); err != nil {
+			holdRows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		holds = append(holds, hold)
+	}
+	holdRows.Close()
+	if err = holdRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seat holds: %w", err)
+	}
+
+	for _, hold := range holds {
+		if err = returnPoolSeats(ctx, tx, eventID, hold.TicketTypeID, hold.PlacesCount); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE seat_allocations
+	SET status = $1,
+	    updated_at = NOW()
+	WHERE event_id = $2 AND status = $3`, AllocationReleased, eventID, AllocationActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release seats: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE ticket_types
+	SET available = capacity,
+	    updated_at = NOW()
+	WHERE event_id = $1`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release ticket type seats: %w", err)
+	}
+
+	// with every booking cancelled all seats are free again
+	query := `UPDATE events
+	SET status = $1,
+	    cancelled_at = NOW(),
+	    available_seats = total_seats,
+	    version = version + 1,
+	    updated_at = NOW()
+	WHERE id = $2
+	RETURNING ` + eventColumns
+
+	var event model.Event
+	if err = tx.QueryRowContext(ctx, query, EventCancelled, eventID).Scan(eventFields(&event)...); err != nil {
+		return nil, fmt.Errorf("failed to cancel event: %w", err)
+	}
+
 	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return &model.EventCancellation{
+		Event:               &event,
+		CancelledBookings:   len(bookings),
+		CancelledWaitlist:   waitlisted,
+		QueuedNotifications: len(recipients),
+	}, nil
 }
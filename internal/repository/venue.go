@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"strings"
+	"time"
+)
+
+const venueColumns = `id, name, address, timezone, capacity, created_at, updated_at`
+
+func venueFields(v *model.Venue) []any {
+	return []any{
+		&v.ID,
+		&v.Name,
+		&v.Address,
+		&v.Timezone,
+		&v.Capacity,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	}
+}
+
+// validVenue reports whether the venue has a name, a positive capacity and a timezone Go knows.
+// "Local" depends on the server and "" means UTC, so neither is accepted as a venue timezone.
+func validVenue(name, timezone string, capacity int) bool {
+	if name == "" || capacity <= 0 || timezone == "" || timezone == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(timezone)
+	return err == nil
+}
+
+// isForeignKeyViolation reports whether err is a violation of the named foreign key constraint.
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.Constraint == constraint
+}
+
+func (r *Postgres) CreateVenue(ctx context.Context, req *dto.CreateVenue) (*model.Venue, error) {
+	name, timezone := strings.TrimSpace(req.Name), strings.TrimSpace(req.Timezone)
+	if !validVenue(name, timezone, req.Capacity) {
+		return nil, ErrInvalidVenue
+	}
+
+	query := `INSERT INTO venues(name, address, timezone, capacity)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + venueColumns
+
+	var created model.Venue
+	err := r.db.QueryRowContext(ctx, query, name, strings.TrimSpace(req.Address), timezone, req.Capacity).Scan(
+		venueFields(&created)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create venue: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *Postgres) GetVenues(ctx context.Context) ([]*model.Venue, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+venueColumns+` FROM venues ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get venues: %w", err)
+	}
+	defer rows.Close()
+
+	venues := []*model.Venue{}
+	for rows.Next() {
+		var venue model.Venue
+		if err = rows.Scan(venueFields(&venue)...); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		venues = append(venues, &venue)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read venues: %w", err)
+	}
+
+	return venues, nil
+}
+
+func (r *Postgres) GetVenueByID(ctx context.Context, id uuid.UUID) (*model.Venue, error) {
+	var venue model.Venue
+	err := r.db.QueryRowContext(ctx, `SELECT `+venueColumns+` FROM venues WHERE id = $1`, id).Scan(venueFields(&venue)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchVenue
+		}
+		return nil, fmt.Errorf("failed to get venue: %w", err)
+	}
+	return &venue, nil
+}
+
+// UpdateVenue changes the fields that are set. A new capacity applies to events created afterwards;
+// a new timezone changes how the times of all events at the venue are shown, not the times themselves.
+func (r *Postgres) UpdateVenue(ctx context.Context, id uuid.UUID, upd *dto.UpdateVenue) (*model.Venue, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var venue model.Venue
+	err = tx.QueryRowContext(ctx, `SELECT `+venueColumns+` FROM venues WHERE id = $1 FOR UPDATE`, id).Scan(
+		venueFields(&venue)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchVenue
+		}
+		return nil, fmt.Errorf("failed to lock venue: %w", err)
+	}
+
+	if upd.Name != nil {
+		venue.Name = strings.TrimSpace(*upd.Name)
+	}
+	if upd.Address != nil {
+		venue.Address = strings.TrimSpace(*upd.Address)
+	}
+	if upd.Timezone != nil {
+		venue.Timezone = strings.TrimSpace(*upd.Timezone)
+	}
+	if upd.Capacity != nil {
+		venue.Capacity = *upd.Capacity
+	}
+	if !validVenue(venue.Name, venue.Timezone, venue.Capacity) {
+		return nil, ErrInvalidVenue
+	}
+
+	var updated model.Venue
+	err = tx.QueryRowContext(ctx, `UPDATE venues
+	SET name = $2,
+	    address = $3,
+	    timezone = $4,
+	    capacity = $5,
+	    updated_at = NOW()
+	WHERE id = $1
+	RETURNING `+venueColumns, id, venue.Name, venue.Address, venue.Timezone, venue.Capacity).Scan(
+		venueFields(&updated)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update venue: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// DeleteVenue removes a venue no event refers to.
+func (r *Postgres) DeleteVenue(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM venues WHERE id = $1`, id)
+	if err != nil {
+		if isForeignKeyViolation(err, "events_venue_id_fkey") || isForeignKeyViolation(err, "seat_maps_venue_id_fkey") {
+			return ErrVenueInUse
+		}
+		return fmt.Errorf("failed to delete venue: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete venue: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNoSuchVenue
+	}
+	return nil
+}
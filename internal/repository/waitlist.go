@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistPromoted  = "promoted"
+	WaitlistCancelled = "cancelled"
+)
+
+// JoinWaitlist puts the user in line for the event. A promoted entry becomes a booking,
+// so it has to fit the same limits and, on an event with a seat map, name its seats.
+func (r *Postgres) JoinWaitlist(ctx context.Context, entry *dto.JoinWaitlist, limits config.Limits) (*model.WaitlistEntry, error) {
+	if entry.PlacesCount <= 0 {
+		return nil, ErrInvalidPlacesCount
+	}
+	seatIDs, err := selectedSeats(entry.PlacesCount, entry.SeatIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.checkTicketType(ctx, entry.EventID, entry.TicketTypeID); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = checkBookingLimits(ctx, tx, limits, entry.TelegramID, entry.EventID, entry.PlacesCount); err != nil {
+		return nil, err
+	}
+
+	if err = checkSeatSelection(ctx, tx, entry.EventID, seatIDs); err != nil {
+		return nil, err
+	}
+
+	// the event is read in the same statement, so nobody joins an event that is being cancelled
+	query := `INSERT INTO waitlist(event_id, telegram_id, places_count, status, ticket_type_id, seat_ids)
+	SELECT id, $2, $3, $4, $6, $7 FROM events WHERE id = $1 AND status = $5
+	RETURNING id, created_at, updated_at`
+
+	var created model.WaitlistEntry
+	err = tx.QueryRowContext(ctx, query, entry.EventID, entry.TelegramID, entry.PlacesCount, WaitlistWaiting, EventPublished,
+		entry.TicketTypeID, pq.Array(seatIDs)).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			event, err := r.GetEventByID(ctx, entry.EventID)
+			if err != nil {
+				if errors.Is(err, ErrEventNotFound) {
+					return nil, ErrNoSuchEvent
+				}
+				return nil, err
+			}
+			return nil, eventStateError(event.Status)
+		}
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	created.EventID = entry.EventID
+	created.TelegramID = entry.TelegramID
+	created.PlacesCount = entry.PlacesCount
+	created.TicketTypeID = entry.TicketTypeID
+	created.SeatIDs = seatIDs
+	created.Status = WaitlistWaiting
+
+	return &created, nil
+}
+
+// checkTicketType reports whether ticketTypeID is a valid choice for the event:
+// it must belong to the event, and it must be set if the event has ticket types.
+func (r *Postgres) checkTicketType(ctx context.Context, eventID uuid.UUID, ticketTypeID *uuid.UUID) error {
+	if ticketTypeID == nil {
+		var hasTypes bool
+		err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM ticket_types WHERE event_id = $1)`, eventID).Scan(&hasTypes)
+		if err != nil {
+			return fmt.Errorf("failed to check ticket types: %w", err)
+		}
+		if hasTypes {
+			return ErrTicketTypeRequired
+		}
+		return nil
+	}
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM ticket_types WHERE id = $1 AND event_id = $2)`,
+		*ticketTypeID, eventID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check ticket type: %w", err)
+	}
+	if !exists {
+		return ErrNoSuchTicketType
+	}
+	return nil
+}
+
+func (r *Postgres) GetWaitlistEntryByID(ctx context.Context, id uuid.UUID) (*model.WaitlistEntry, error) {
+	query := `SELECT id, event_id, telegram_id, places_count, ticket_type_id, seat_ids, status, booking_id, created_at, updated_at
+	FROM waitlist WHERE id = $1`
+
+	var entry model.WaitlistEntry
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&entry.ID,
+		&entry.EventID,
+		&entry.TelegramID,
+		&entry.PlacesCount,
+		&entry.TicketTypeID,
+		pq.Array(&entry.SeatIDs),
+		&entry.Status,
+		&entry.BookingID,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchWaitlistEntry
+		}
+		return nil, fmt.Errorf("failed to get waitlist entry from db: %w", err)
+	}
+	return &entry, nil
+}
+
+// PromoteWaitlist turns the oldest waiting entries of the event that fit into the free seats
+// and into the user's limits into pending bookings. On an event with a seat map an entry also
+// needs all of its chosen seats to be free. Entries that do not fit are skipped
+// and keep their place in line.
+func (r *Postgres) PromoteWaitlist(ctx context.Context, eventID uuid.UUID, limits config.Limits) ([]*model.Booking, error) {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// locking the event row serializes promotions with each other and with new bookings
+	var available int
+	var status string
+	var onSale bool
+	err = tx.QueryRowContext(ctx, `SELECT available_seats, status, sale_opens_at <= NOW() AND sale_closes_at > NOW()
+	FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&available, &status, &onSale)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchEvent
+		}
+		return nil, fmt.Errorf("failed to lock event: %w", err)
+	}
+
+	// the waitlist keeps its place while sales are closed and moves again once they reopen
+	if available == 0 || status != EventPublished || !onSale {
+		return nil, nil
+	}
+
+	typeAvailable, err := lockTicketTypeSeats(ctx, tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var hasMap bool
+	if err = tx.QueryRowContext(ctx, `SELECT `+eventSeatMapID+` IS NOT NULL`, eventID).Scan(&hasMap); err != nil {
+		return nil, fmt.Errorf("failed to check seat map: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, telegram_id, places_count, ticket_type_id, seat_ids
+	FROM waitlist
+	WHERE event_id = $1 AND status = $2
+	ORDER BY created_at
+	FOR UPDATE`, eventID, WaitlistWaiting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waitlist: %w", err)
+	}
+
+	var entries []model.WaitlistEntry
+	for rows.Next() {
+		var entry model.WaitlistEntry
+		if err = rows.Scan(&entry.ID, &entry.TelegramID, &entry.PlacesCount, &entry.TicketTypeID, pq.Array(&entry.SeatIDs)); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read waitlist: %w", err)
+	}
+
+	var promoted []*model.Booking
+	for _, entry := range entries {
+		if available == 0 {
+			break
+		}
+		if entry.PlacesCount > available {
+			continue
+		}
+		// entries of a sold out ticket type wait for that type; entries without a type
+		// can not be booked once the event has types
+		if len(typeAvailable) > 0 {
+			if entry.TicketTypeID == nil || entry.PlacesCount > typeAvailable[*entry.TicketTypeID] {
+				continue
+			}
+		}
+
+		// a shared pool may run out even though the event still has seats
+		poolAvailable, pooled, err := availablePoolSeats(ctx, tx, eventID, entry.TicketTypeID)
+		if err != nil {
+			return nil, err
+		}
+		if pooled && entry.PlacesCount > poolAvailable {
+			continue
+		}
+
+		// entries that joined before the event got its seat map have no seats to give
+		if hasMap {
+			if len(entry.SeatIDs) == 0 {
+				continue
+			}
+			free, err := seatsFree(ctx, tx, eventID, entry.SeatIDs)
+			if err != nil {
+				return nil, err
+			}
+			if !free {
+				continue
+			}
+		}
+
+		fits, err := fitsBookingLimits(ctx, tx, limits, entry.TelegramID, eventID, entry.PlacesCount)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			continue
+		}
+
+		if err = reserveSeats(ctx, tx, eventID, entry.TicketTypeID, entry.PlacesCount); err != nil {
+			return nil, err
+		}
+
+		booking, err := insertBooking(ctx, tx, eventID, entry.TicketTypeID, entry.TelegramID, entry.PlacesCount,
+			dto.StatusChange{Actor: ActorSystem, Reason: ReasonPromotedFromWaitlist})
+		if err != nil {
+			return nil, err
+		}
+
+		if hasMap {
+			if booking.SeatIDs, err = allocateSeats(ctx, tx, eventID, seatOwner{bookingID: &booking.ID}, entry.SeatIDs); err != nil {
+				return nil, err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE waitlist
+		SET status = $1,
+		    booking_id = $2,
+		    updated_at = NOW()
+		WHERE id = $3`, WaitlistPromoted, booking.ID, entry.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark waitlist entry promoted: %w", err)
+		}
+
+		available -= entry.PlacesCount
+		if entry.TicketTypeID != nil {
+			typeAvailable[*entry.TicketTypeID] -= entry.PlacesCount
+		}
+		promoted = append(promoted, booking)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return promoted, nil
+}
+
+// lockTicketTypeSeats locks the ticket types of the event and returns their free seats.
+func lockTicketTypeSeats(ctx context.Context, tx *sql.Tx, eventID uuid.UUID) (map[uuid.UUID]int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, available FROM ticket_types WHERE event_id = $1 FOR UPDATE`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ticket types: %w", err)
+	}
+	defer rows.Close()
+
+	available := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var id uuid.UUID
+		var seats int
+		if err = rows.Scan(&id, &seats); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		available[id] = seats
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ticket types: %w", err)
+	}
+
+	return available, nil
+}
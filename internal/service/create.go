@@ -4,27 +4,53 @@ import (
 	"context"
 	"github.com/K1la/event-booker/internal/dto"
 	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
 	"github.com/wb-go/wbf/zlog"
+	"time"
 )
 
 func (s *Service) CreateEvent(ctx context.Context, event *dto.CreateEvent) (*model.Event, error) {
+	seats, err := s.venueSeats(ctx, event.VenueID, event.TotalSeats)
+	if err != nil {
+		return nil, err
+	}
+	event.TotalSeats = seats
+
+	s.defaultSalesWindow(event, time.Now())
 	return s.db.CreateEvent(ctx, event)
 }
 
-func (s *Service) CreateBooking(ctx context.Context, booking *dto.CreateBooking) (*model.Booking, error) {
-	createBooking, err := s.db.CreateBooking(ctx, booking)
-	if err != nil {
-		return nil, err
+// defaultSalesWindow opens the sales at now and closes them the sales cutoff before the event, unless the event says otherwise.
+func (s *Service) defaultSalesWindow(event *dto.CreateEvent, now time.Time) {
+	if event.SaleOpensAt == nil {
+		event.SaleOpensAt = &now
 	}
+	if event.SaleClosesAt == nil {
+		closesAt := event.EventAt.Add(-s.salesCutoff)
+		// an event starting within the cutoff is still sold until it starts
+		if !closesAt.After(*event.SaleOpensAt) {
+			closesAt = event.EventAt
+		}
+		event.SaleClosesAt = &closesAt
+	}
+}
 
-	var msg dto.QueueMessage
-	msg.BookingID = createBooking.ID
-	msg.PlacesCount = createBooking.PlacesCount
+func (s *Service) CreateTicketType(ctx context.Context, eventID uuid.UUID, req *dto.CreateTicketType) (*model.TicketType, error) {
+	return s.db.CreateTicketType(ctx, eventID, req)
+}
 
-	if err = s.rbmq.Publish(msg); err != nil {
+func (s *Service) CreateBooking(ctx context.Context, booking *dto.CreateBooking) (*model.Booking, error) {
+	// picking seats says how many places are booked
+	if booking.PlacesCount == 0 {
+		booking.PlacesCount = len(booking.SeatIDs)
+	}
+
+	// the limits, the event state and the sales window are all checked in the booking transaction
+	createBooking, err := s.db.CreateBooking(ctx, booking, s.limits)
+	if err != nil {
 		return nil, err
 	}
 
-	zlog.Logger.Info().Msgf("successfule publish to queue & created Booking: %v", createBooking)
+	zlog.Logger.Info().Msgf("created Booking, expiry queued in outbox: %v", createBooking)
 	return createBooking, nil
 }
@@ -0,0 +1,10 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+func (s *Service) DeleteEvent(ctx context.Context, eventID uuid.UUID) error {
+	return s.db.DeleteEvent(ctx, eventID)
+}
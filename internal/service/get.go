@@ -8,7 +8,29 @@ import (
 )
 
 func (s *Service) GetEventByID(ctx context.Context, eventID uuid.UUID) (*model.Event, error) {
-	return s.db.GetEventByID(ctx, eventID)
+	event, err := s.db.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.TicketTypes, err = s.db.GetTicketTypes(ctx, eventID); err != nil {
+		return nil, err
+	}
+	if event.HeldSeats, err = s.db.GetHeldSeats(ctx, eventID); err != nil {
+		return nil, err
+	}
+	if event.Seats, err = s.db.GetEventSeats(ctx, eventID); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (s *Service) GetTicketTypes(ctx context.Context, eventID uuid.UUID) ([]model.TicketType, error) {
+	if _, err := s.db.GetEventByID(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return s.db.GetTicketTypes(ctx, eventID)
 }
 func (s *Service) GetEvents(ctx context.Context) ([]*model.Event, error) {
 	return s.db.GetEvents(ctx)
@@ -17,3 +39,14 @@ func (s *Service) GetEvents(ctx context.Context) ([]*model.Event, error) {
 func (s *Service) GetBookingByID(ctx context.Context, id uuid.UUID) (*dto.Booking, error) {
 	return s.db.GetBookingByID(ctx, id)
 }
+
+func (s *Service) GetBookingHistory(ctx context.Context, bookingID uuid.UUID) ([]*model.BookingHistoryEntry, error) {
+	if _, err := s.db.GetBookingByID(ctx, bookingID); err != nil {
+		return nil, err
+	}
+	return s.db.GetBookingHistory(ctx, bookingID)
+}
+
+func (s *Service) GetEventNotifications(ctx context.Context, eventID uuid.UUID) (*model.NotificationReport, error) {
+	return s.db.GetEventNotifications(ctx, eventID)
+}
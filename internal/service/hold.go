@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const (
+	defaultHoldTTL           = 5 * time.Minute
+	defaultHoldSweepInterval = 5 * time.Second
+	defaultHoldSweepBatch    = 100
+)
+
+// CreateHold keeps seats for the user for the configured time. Holds count against the same
+// limits as bookings, so they can not be used to lock an event's capacity either.
+func (s *Service) CreateHold(ctx context.Context, hold *dto.CreateHold) (*model.SeatHold, error) {
+	if hold.PlacesCount == 0 {
+		hold.PlacesCount = len(hold.SeatIDs)
+	}
+	return s.db.CreateHold(ctx, hold, s.holdTTL, s.limits)
+}
+
+func (s *Service) GetHold(ctx context.Context, holdID uuid.UUID) (*model.SeatHold, error) {
+	return s.db.GetHoldByID(ctx, holdID)
+}
+
+// ConvertHold books the held seats. The booking gets its own payment deadline like any other.
+func (s *Service) ConvertHold(ctx context.Context, holdID uuid.UUID, req *dto.ConvertHold) (*model.Booking, error) {
+	booking, err := s.db.ConvertHold(ctx, holdID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	zlog.Logger.Info().Msgf("converted seat hold %s to booking, expiry queued in outbox: %v", holdID, booking)
+	return booking, nil
+}
+
+func (s *Service) ReleaseHold(ctx context.Context, holdID uuid.UUID) (*model.SeatHold, error) {
+	hold, err := s.db.ReleaseHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.promoteWaitlist(ctx, hold.EventID)
+
+	return hold, nil
+}
+
+// StartHoldSweeper periodically lapses seat holds that were not booked in time and gives their
+// seats back. Holds are short-lived and never go through the payment-expiry queue,
+// so this loop is their only expiry path.
+func (s *Service) StartHoldSweeper(ctx context.Context, interval time.Duration, batchSize int) {
+	if interval <= 0 {
+		interval = defaultHoldSweepInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultHoldSweepBatch
+	}
+
+	zlog.Logger.Info().Msgf("started hold sweeper, interval %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("hold sweeper stopped")
+				return
+			case <-ticker.C:
+				s.sweepExpiredHolds(ctx, batchSize)
+			}
+		}
+	}()
+}
+
+func (s *Service) sweepExpiredHolds(ctx context.Context, batchSize int) {
+	eventIDs, err := s.db.ExpireHolds(ctx, batchSize)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to expire seat holds")
+		return
+	}
+
+	for _, eventID := range eventIDs {
+		s.promoteWaitlist(ctx, eventID)
+	}
+}
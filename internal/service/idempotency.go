@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/wb-go/wbf/zlog"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultIdempotencyTTL             = 24 * time.Hour
+	defaultIdempotencyCleanupInterval = time.Hour
+	defaultIdempotencyLease           = 30 * time.Second
+)
+
+// BeginIdempotentRequest claims the key for the request with the given fingerprint.
+// It returns nil when the request is new, or its earlier attempt lost the lease, and must be
+// processed, or the stored record whose response has to be replayed.
+func (s *Service) BeginIdempotentRequest(ctx context.Context, scope, key, fingerprint string) (*model.IdempotencyRecord, error) {
+	record, created, err := s.db.ReserveIdempotencyKey(ctx, scope, key, fingerprint, s.idempotencyLease)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		return nil, nil
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, repository.ErrIdempotencyKeyReused
+	}
+	if record.StatusCode == 0 {
+		return nil, repository.ErrIdempotencyKeyInProgress
+	}
+
+	return record, nil
+}
+
+// CompleteIdempotentRequest stores the response for replays. Server errors are not stored:
+// the key is released so that the client can retry the same request.
+func (s *Service) CompleteIdempotentRequest(ctx context.Context, scope, key string, statusCode int, response []byte) error {
+	if statusCode >= http.StatusInternalServerError {
+		return s.db.ReleaseIdempotencyKey(ctx, scope, key)
+	}
+	return s.db.CompleteIdempotencyKey(ctx, scope, key, statusCode, response)
+}
+
+// StartIdempotencyKeyCleanup periodically deletes idempotency keys older than ttl.
+func (s *Service) StartIdempotencyKeyCleanup(ctx context.Context, ttl, interval time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if interval <= 0 {
+		interval = defaultIdempotencyCleanupInterval
+	}
+
+	zlog.Logger.Info().Msgf("started idempotency key cleanup, ttl %s", ttl)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("idempotency key cleanup stopped")
+				return
+			case <-ticker.C:
+				deleted, err := s.db.DeleteExpiredIdempotencyKeys(ctx, ttl)
+				if err != nil {
+					zlog.Logger.Error().Err(err).Msg("failed to delete expired idempotency keys")
+					continue
+				}
+				if deleted > 0 {
+					zlog.Logger.Info().Msgf("deleted %d expired idempotency keys", deleted)
+				}
+			}
+		}
+	}()
+}
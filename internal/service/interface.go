@@ -2,16 +2,45 @@ package service
 
 import (
 	"context"
+	"github.com/K1la/event-booker/internal/config"
 	"github.com/K1la/event-booker/internal/dto"
 	"github.com/K1la/event-booker/internal/model"
 	"github.com/google/uuid"
+	"time"
 )
 
 type DBRepo interface {
 	CreateEvent(ctx context.Context, event *dto.CreateEvent) (*model.Event, error)
-	CreateBooking(ctx context.Context, booking *dto.CreateBooking) (*model.Booking, error)
-	ConfirmBookingPayment(ctx context.Context, eventID uuid.UUID) error
-	CancelBooking(ctx context.Context, booking *dto.QueueMessage) error
+	UpdateEvent(ctx context.Context, eventID uuid.UUID, upd *dto.UpdateEvent) (*model.Event, error)
+	DeleteEvent(ctx context.Context, eventID uuid.UUID) error
+	CancelEvent(ctx context.Context, eventID uuid.UUID, bookingText, waitlistText string) (*model.EventCancellation, error)
+	RescheduleEvent(ctx context.Context, eventID uuid.UUID, req *dto.RescheduleEvent, text string) (*model.RescheduleResult, error)
+	SetEventStatus(ctx context.Context, eventID uuid.UUID, status string) (*model.Event, error)
+	CompletePastEvents(ctx context.Context) (int64, error)
+	CreateEventSeries(ctx context.Context, req *dto.CreateEventSeries, occurrences []*dto.CreateEvent) (*model.EventSeries, error)
+	GetEventSeries(ctx context.Context, id uuid.UUID) (*model.EventSeries, error)
+	UpdateEventSeries(ctx context.Context, id uuid.UUID, upd *dto.UpdateEventSeries) (*model.SeriesUpdate, error)
+	CreateCapacityPool(ctx context.Context, req *dto.CreateCapacityPool) (*model.CapacityPool, error)
+	GetCapacityPools(ctx context.Context) ([]*model.CapacityPool, error)
+	GetCapacityPoolByID(ctx context.Context, id uuid.UUID) (*model.CapacityPool, error)
+	UpdateCapacityPool(ctx context.Context, id uuid.UUID, upd *dto.UpdateCapacityPool) (*model.CapacityPool, error)
+	GetPoolEventIDs(ctx context.Context, poolID uuid.UUID) ([]uuid.UUID, error)
+	GetPooledEventIDs(ctx context.Context, eventID uuid.UUID) ([]uuid.UUID, error)
+	CreateSeatMap(ctx context.Context, req *dto.CreateSeatMap) (*model.SeatMap, error)
+	GetSeatMap(ctx context.Context, id uuid.UUID) (*model.SeatMap, error)
+	GetEventSeats(ctx context.Context, eventID uuid.UUID) ([]model.EventSeat, error)
+	CreateVenue(ctx context.Context, req *dto.CreateVenue) (*model.Venue, error)
+	GetVenues(ctx context.Context) ([]*model.Venue, error)
+	GetVenueByID(ctx context.Context, id uuid.UUID) (*model.Venue, error)
+	UpdateVenue(ctx context.Context, id uuid.UUID, upd *dto.UpdateVenue) (*model.Venue, error)
+	DeleteVenue(ctx context.Context, id uuid.UUID) error
+	CreateTicketType(ctx context.Context, eventID uuid.UUID, req *dto.CreateTicketType) (*model.TicketType, error)
+	GetTicketTypes(ctx context.Context, eventID uuid.UUID) ([]model.TicketType, error)
+	CreateBooking(ctx context.Context, booking *dto.CreateBooking, limits config.Limits) (*model.Booking, error)
+	ConfirmBooking(ctx context.Context, bookingID uuid.UUID, change dto.StatusChange) (*model.Booking, error)
+	CancelBooking(ctx context.Context, bookingID uuid.UUID, change dto.StatusChange, refundPercent int) (*model.Booking, error)
+	ExpireBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error)
+	OptOutBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error)
 
 	//DeleteBooking(ctx context.Context, msg dto.QueueMessage) error
 
@@ -19,13 +48,65 @@ type DBRepo interface {
 	GetEvents(ctx context.Context) ([]*model.Event, error)
 
 	GetBookingByID(ctx context.Context, id uuid.UUID) (*dto.Booking, error)
+	GetOverdueBookings(ctx context.Context, limit int) ([]uuid.UUID, error)
+	GetBookingHistory(ctx context.Context, bookingID uuid.UUID) ([]*model.BookingHistoryEntry, error)
+
+	JoinWaitlist(ctx context.Context, entry *dto.JoinWaitlist, limits config.Limits) (*model.WaitlistEntry, error)
+	GetWaitlistEntryByID(ctx context.Context, id uuid.UUID) (*model.WaitlistEntry, error)
+	PromoteWaitlist(ctx context.Context, eventID uuid.UUID, limits config.Limits) ([]*model.Booking, error)
+
+	GetUnpublishedOutbox(ctx context.Context, limit int) ([]*model.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, id uuid.UUID) error
+	MarkOutboxFailed(ctx context.Context, id uuid.UUID, cause error) error
+
+	GetQueuedNotifications(ctx context.Context, limit int) ([]*model.Notification, error)
+	MarkNotificationSent(ctx context.Context, id uuid.UUID) error
+	MarkNotificationFailed(ctx context.Context, id uuid.UUID, cause error, maxAttempts int) error
+	GetEventNotifications(ctx context.Context, eventID uuid.UUID) (*model.NotificationReport, error)
+
+	CreatePayment(ctx context.Context, payment *model.Payment) (*model.Payment, error)
+	GetPendingPayment(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error)
+	GetPaymentByProviderID(ctx context.Context, provider, providerPaymentID string) (*model.Payment, error)
+	CompletePayment(ctx context.Context, paymentID uuid.UUID) (*model.Payment, *model.Booking, error)
+	FailPayment(ctx context.Context, paymentID uuid.UUID) error
+
+	CreatePromoCode(ctx context.Context, req *dto.CreatePromoCode) (*model.PromoCode, error)
+	GetPromoCodes(ctx context.Context) ([]*model.PromoCode, error)
+	GetPromoCodeReport(ctx context.Context, id uuid.UUID) (*model.PromoCodeReport, error)
+
+	CreateHold(ctx context.Context, hold *dto.CreateHold, ttl time.Duration, limits config.Limits) (*model.SeatHold, error)
+	GetHoldByID(ctx context.Context, id uuid.UUID) (*model.SeatHold, error)
+	GetHeldSeats(ctx context.Context, eventID uuid.UUID) (int, error)
+	ConvertHold(ctx context.Context, holdID uuid.UUID, req *dto.ConvertHold) (*model.Booking, error)
+	ReleaseHold(ctx context.Context, holdID uuid.UUID) (*model.SeatHold, error)
+	ExpireHolds(ctx context.Context, limit int) ([]uuid.UUID, error)
+
+	GetPendingRefunds(ctx context.Context, limit int) ([]*model.Refund, error)
+	GetBookingRefunds(ctx context.Context, bookingID uuid.UUID) ([]*model.Refund, error)
+	MarkRefundSucceeded(ctx context.Context, id uuid.UUID, providerRefundID string) error
+	MarkRefundFailed(ctx context.Context, id uuid.UUID, cause error, maxAttempts int) error
+
+	ReserveIdempotencyKey(ctx context.Context, scope, key, fingerprint string, lease time.Duration) (*model.IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, response []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int64, error)
 }
 
 type RabbitMQ interface {
-	Publish(booking dto.QueueMessage) error
+	Publish(booking dto.QueueMessage, delay time.Duration) error
 	Consume(ctx context.Context) (<-chan []byte, error)
 }
 
+// PaymentProvider collects the price of a booking on the provider's checkout page,
+// reports the outcome to the webhook and returns money on refunds.
+type PaymentProvider interface {
+	Name() string
+	CreatePaymentIntent(ctx context.Context, req dto.PaymentIntentRequest) (*dto.PaymentIntent, error)
+	// ParseWebhook verifies the signature of the webhook body before decoding it.
+	ParseWebhook(payload []byte, signature string) (*dto.PaymentWebhook, error)
+	Refund(ctx context.Context, req dto.RefundRequest) (*dto.RefundResult, error)
+}
+
 type Sender interface {
 	SendToTelegram(telegramId int, text string) error
 }
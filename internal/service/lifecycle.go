@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const defaultCompleteInterval = time.Minute
+
+// SetEventStatus is the admin transition of an event. Cancelling goes through CancelEvent,
+// so bookings are cancelled and attendees are notified the same way as with the cancel endpoint.
+func (s *Service) SetEventStatus(ctx context.Context, eventID uuid.UUID, req *dto.SetEventStatus) (*model.Event, error) {
+	switch req.Status {
+	case repository.EventCancelled:
+		cancellation, err := s.CancelEvent(ctx, eventID, &dto.CancelEvent{})
+		if err != nil {
+			return nil, err
+		}
+		return cancellation.Event, nil
+	case repository.EventDraft, repository.EventPublished, repository.EventSalesClosed, repository.EventCompleted:
+	default:
+		return nil, fmt.Errorf("%w: %q", repository.ErrUnknownEventStatus, req.Status)
+	}
+
+	event, err := s.db.SetEventStatus(ctx, eventID, req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	// seats that came back while sales were closed go to the waitlist once they reopen
+	if event.Status == repository.EventPublished {
+		s.promoteWaitlist(ctx, eventID)
+	}
+
+	return event, nil
+}
+
+// StartEventCompleter periodically moves events whose time has passed to completed,
+// which also stops their sales.
+func (s *Service) StartEventCompleter(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCompleteInterval
+	}
+
+	zlog.Logger.Info().Msgf("started event completer, interval %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("event completer stopped")
+				return
+			case <-ticker.C:
+				completed, err := s.db.CompletePastEvents(ctx)
+				if err != nil {
+					zlog.Logger.Error().Err(err).Msg("failed to complete past events")
+					continue
+				}
+				if completed > 0 {
+					zlog.Logger.Info().Msgf("completed %d past events", completed)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const (
+	defaultNotifyInterval    = 5 * time.Second
+	defaultNotifyBatchSize   = 100
+	defaultNotifyMaxAttempts = 5
+)
+
+// StartNotifier periodically delivers the queued notifications through the Sender.
+// A failed send is retried on the next runs until maxAttempts, after which the
+// notification is marked failed and shows up in the event's notification report.
+func (s *Service) StartNotifier(ctx context.Context, interval time.Duration, batchSize, maxAttempts int) {
+	if interval <= 0 {
+		interval = defaultNotifyInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultNotifyBatchSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultNotifyMaxAttempts
+	}
+
+	zlog.Logger.Info().Msgf("started notifier, interval %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("notifier stopped")
+				return
+			case <-ticker.C:
+				s.deliverNotifications(ctx, batchSize, maxAttempts)
+			}
+		}
+	}()
+}
+
+func (s *Service) deliverNotifications(ctx context.Context, batchSize, maxAttempts int) {
+	notifications, err := s.db.GetQueuedNotifications(ctx, batchSize)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to read queued notifications")
+		return
+	}
+
+	for _, n := range notifications {
+		if err = s.sender.SendToTelegram(n.TelegramID, n.Text); err != nil {
+			zlog.Logger.Error().Err(err).Interface("notificationID", n.ID).Msg("failed to send notification")
+			if err = s.db.MarkNotificationFailed(ctx, n.ID, err, maxAttempts); err != nil {
+				zlog.Logger.Error().Err(err).Msg("failed to record notification failure")
+			}
+			continue
+		}
+
+		if err = s.db.MarkNotificationSent(ctx, n.ID); err != nil {
+			// the message will be sent again on the next run
+			zlog.Logger.Error().Err(err).Interface("notificationID", n.ID).Msg("failed to mark notification sent")
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const (
+	defaultRelayInterval  = 5 * time.Second
+	defaultRelayBatchSize = 100
+)
+
+// StartOutboxRelay periodically publishes the booking expiry messages written to the outbox.
+// A message stays in the outbox until the broker accepts it, so a broker outage only delays expiry.
+func (s *Service) StartOutboxRelay(ctx context.Context, interval time.Duration, batchSize int) {
+	if interval <= 0 {
+		interval = defaultRelayInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultRelayBatchSize
+	}
+
+	zlog.Logger.Info().Msgf("started outbox relay, interval %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("outbox relay stopped")
+				return
+			case <-ticker.C:
+				s.relayOutbox(ctx, batchSize)
+			}
+		}
+	}()
+}
+
+func (s *Service) relayOutbox(ctx context.Context, batchSize int) {
+	messages, err := s.db.GetUnpublishedOutbox(ctx, batchSize)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to read outbox")
+		return
+	}
+
+	for _, m := range messages {
+		msg := dto.QueueMessage{BookingID: m.BookingID}
+
+		if err = s.rbmq.Publish(msg, time.Until(m.DeliverAt)); err != nil {
+			zlog.Logger.Error().Err(err).Interface("bookingID", m.BookingID).Msg("failed to publish outbox message")
+			if err = s.db.MarkOutboxFailed(ctx, m.ID, err); err != nil {
+				zlog.Logger.Error().Err(err).Msg("failed to record outbox failure")
+			}
+			continue
+		}
+
+		if err = s.db.MarkOutboxPublished(ctx, m.ID); err != nil {
+			// the message will be published again; the expiry handler ignores bookings that are no longer pending
+			zlog.Logger.Error().Err(err).Interface("bookingID", m.BookingID).Msg("failed to mark outbox message published")
+		}
+	}
+}
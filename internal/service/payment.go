@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// PayBooking starts the payment of a pending booking and returns the payment with its checkout URL.
+// While a payment is waiting for the provider, the same payment is returned again.
+func (s *Service) PayBooking(ctx context.Context, bookingID uuid.UUID) (*model.Payment, error) {
+	booking, err := s.db.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !repository.CanTransition(booking.Status, repository.StatusConfirmed) {
+		return nil, fmt.Errorf("%w: %s -> %s",
+			repository.ErrInvalidStatusTransition, booking.Status, repository.StatusConfirmed)
+	}
+	if booking.PriceMinor == 0 {
+		return nil, repository.ErrNothingToPay
+	}
+
+	pending, err := s.db.GetPendingPayment(ctx, bookingID)
+	if err == nil {
+		return pending, nil
+	}
+	if !errors.Is(err, repository.ErrNoSuchPayment) {
+		return nil, err
+	}
+	if s.payments == nil {
+		return nil, repository.ErrPaymentsDisabled
+	}
+
+	intent, err := s.payments.CreatePaymentIntent(ctx, dto.PaymentIntentRequest{
+		BookingID:   bookingID,
+		AmountMinor: booking.PriceMinor,
+		Currency:    booking.Currency,
+		Description: fmt.Sprintf("%d seat(s) for event (%v)", booking.PlacesCount, booking.EventTitle),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	return s.db.CreatePayment(ctx, &model.Payment{
+		BookingID:         bookingID,
+		Provider:          s.payments.Name(),
+		ProviderPaymentID: intent.ProviderPaymentID,
+		AmountMinor:       booking.PriceMinor,
+		Currency:          booking.Currency,
+		CheckoutURL:       intent.CheckoutURL,
+	})
+}
+
+// HandlePaymentWebhook applies a payment outcome reported by the provider.
+// The signature is checked by the provider before anything is read from the body.
+func (s *Service) HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) error {
+	if s.payments == nil {
+		return repository.ErrPaymentsDisabled
+	}
+
+	webhook, err := s.payments.ParseWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.db.GetPaymentByProviderID(ctx, s.payments.Name(), webhook.ProviderPaymentID)
+	if err != nil {
+		return err
+	}
+
+	switch webhook.Status {
+	case repository.PaymentSucceeded:
+		completed, booking, err := s.db.CompletePayment(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+		if booking == nil {
+			zlog.Logger.Warn().Interface("paymentID", completed.ID).Interface("bookingID", completed.BookingID).
+				Msg("payment succeeded for a booking that is no longer pending")
+			return nil
+		}
+
+		if booking.TelegramID != 0 {
+			tgMsg := fmt.Sprintf("Payment received, your booking %s is confirmed", booking.ID)
+			if err = s.sender.SendToTelegram(booking.TelegramID, tgMsg); err != nil {
+				zlog.Logger.Error().Err(err).Interface("bookingID", booking.ID).Msg("failed to notify about payment")
+			}
+		}
+		return nil
+	case repository.PaymentFailed:
+		return s.db.FailPayment(ctx, payment.ID)
+	default:
+		return fmt.Errorf("%w: %q", repository.ErrUnknownPaymentStatus, webhook.Status)
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func (s *Service) CreateCapacityPool(ctx context.Context, req *dto.CreateCapacityPool) (*model.CapacityPool, error) {
+	return s.db.CreateCapacityPool(ctx, req)
+}
+
+func (s *Service) GetCapacityPools(ctx context.Context) ([]*model.CapacityPool, error) {
+	return s.db.GetCapacityPools(ctx)
+}
+
+func (s *Service) GetCapacityPoolByID(ctx context.Context, id uuid.UUID) (*model.CapacityPool, error) {
+	return s.db.GetCapacityPoolByID(ctx, id)
+}
+
+func (s *Service) UpdateCapacityPool(ctx context.Context, id uuid.UUID, upd *dto.UpdateCapacityPool) (*model.CapacityPool, error) {
+	updated, err := s.db.UpdateCapacityPool(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// a bigger pool may let waiting users of its events in, including the events
+	// that draw from it only through a ticket type
+	if upd.Capacity != nil {
+		eventIDs, err := s.db.GetPoolEventIDs(ctx, id)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Interface("poolID", id).Msg("failed to get pool events")
+			eventIDs = updated.EventIDs
+		}
+		for _, eventID := range eventIDs {
+			s.promoteEventWaitlist(ctx, eventID)
+		}
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+)
+
+func (s *Service) CreatePromoCode(ctx context.Context, req *dto.CreatePromoCode) (*model.PromoCode, error) {
+	return s.db.CreatePromoCode(ctx, req)
+}
+
+func (s *Service) GetPromoCodes(ctx context.Context) ([]*model.PromoCode, error) {
+	return s.db.GetPromoCodes(ctx)
+}
+
+func (s *Service) GetPromoCodeReport(ctx context.Context, id uuid.UUID) (*model.PromoCodeReport, error) {
+	return s.db.GetPromoCodeReport(ctx, id)
+}
@@ -3,9 +3,11 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/K1la/event-booker/internal/dto"
 	"github.com/K1la/event-booker/internal/repository"
+	"github.com/google/uuid"
 	"github.com/wb-go/wbf/zlog"
 )
 
@@ -41,20 +43,34 @@ func (s *Service) handleQueueMessage(ctx context.Context, msgData []byte) error
 		return fmt.Errorf("failed to unmarshal queueMessage: %w", err)
 	}
 
-	bookingInfo, err := s.db.GetBookingByID(ctx, msg.BookingID)
+	return s.expireBooking(ctx, msg.BookingID)
+}
+
+// expireBooking releases an unpaid booking, hands its seats to the waitlist and tells the user.
+// It is shared by the delayed queue and the database sweeper, so whichever sees the booking
+// first expires it and the other one finds it no longer pending.
+func (s *Service) expireBooking(ctx context.Context, bookingID uuid.UUID) error {
+	bookingInfo, err := s.db.GetBookingByID(ctx, bookingID)
 	if err != nil {
 		return err
 	}
 
-	if bookingInfo.Status == repository.StatusConfirmed {
-		zlog.Logger.Info().Msgf("booking is already confirmed, id: %s", msg.BookingID)
+	if !repository.CanTransition(bookingInfo.Status, repository.StatusExpired) {
+		zlog.Logger.Info().Msgf("booking is already %s, id: %s", bookingInfo.Status, bookingID)
 		return nil
 	}
 
-	if err = s.db.CancelBooking(ctx, &msg); err != nil {
+	expired, err := s.db.ExpireBooking(ctx, bookingID)
+	if err != nil {
+		if errors.Is(err, repository.ErrBookingNotFoundOrAlreadyCancelled) {
+			zlog.Logger.Info().Msgf("booking left pending before expiry, id: %s", bookingID)
+			return nil
+		}
 		return err
 	}
 
+	s.promoteWaitlist(ctx, expired.EventID)
+
 	if bookingInfo.TelegramID != 0 {
 		tgMsg := fmt.Sprintf("Your booking to event (%v) was cancelled due to unpaid status", bookingInfo.EventTitle)
 		if err = s.sender.SendToTelegram(bookingInfo.TelegramID, tgMsg); err != nil {
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/K1la/event-booker/internal/config"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+	"slices"
+	"time"
+)
+
+const (
+	defaultRefundInterval    = 10 * time.Second
+	defaultRefundBatchSize   = 50
+	defaultRefundMaxAttempts = 5
+)
+
+type refundRule struct {
+	minBefore time.Duration
+	percent   int
+}
+
+// refundPolicy decides which share of the payment a cancelled booking gets back,
+// ordered from the earliest cancellation to the latest.
+type refundPolicy []refundRule
+
+// newRefundPolicy falls back to a full refund until the event starts when no rules are configured.
+func newRefundPolicy(rules []config.RefundRule) refundPolicy {
+	if len(rules) == 0 {
+		return refundPolicy{{percent: repository.FullRefundPercent}}
+	}
+
+	policy := make(refundPolicy, 0, len(rules))
+	for _, rule := range rules {
+		policy = append(policy, refundRule{
+			minBefore: time.Duration(rule.MinHoursBefore) * time.Hour,
+			percent:   min(max(rule.Percent, 0), repository.FullRefundPercent),
+		})
+	}
+	slices.SortFunc(policy, func(a, b refundRule) int {
+		return cmp.Compare(b.minBefore, a.minBefore)
+	})
+
+	return policy
+}
+
+// percent returns the share refunded for a cancellation at now. Once the event has started nothing is refunded.
+func (p refundPolicy) percent(eventAt, now time.Time) int {
+	left := eventAt.Sub(now)
+	if left < 0 {
+		return 0
+	}
+
+	for _, rule := range p {
+		if left >= rule.minBefore {
+			return rule.percent
+		}
+	}
+	return 0
+}
+
+func (s *Service) GetBookingRefunds(ctx context.Context, bookingID uuid.UUID) ([]*model.Refund, error) {
+	if _, err := s.db.GetBookingByID(ctx, bookingID); err != nil {
+		return nil, err
+	}
+	return s.db.GetBookingRefunds(ctx, bookingID)
+}
+
+// StartRefunder periodically sends the refunds queued by cancellations to the payment provider
+// and tells the attendee about each one that went through. A rejected refund is retried
+// on the next runs until maxAttempts, after which it is marked failed.
+func (s *Service) StartRefunder(ctx context.Context, interval time.Duration, batchSize, maxAttempts int) {
+	if interval <= 0 {
+		interval = defaultRefundInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultRefundBatchSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRefundMaxAttempts
+	}
+	if s.payments == nil {
+		zlog.Logger.Warn().Msg("no payment provider configured, refunds stay queued")
+		return
+	}
+
+	zlog.Logger.Info().Msgf("started refunder, interval %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("refunder stopped")
+				return
+			case <-ticker.C:
+				s.processRefunds(ctx, batchSize, maxAttempts)
+			}
+		}
+	}()
+}
+
+func (s *Service) processRefunds(ctx context.Context, batchSize, maxAttempts int) {
+	refunds, err := s.db.GetPendingRefunds(ctx, batchSize)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to read pending refunds")
+		return
+	}
+
+	for _, refund := range refunds {
+		result, err := s.refund(ctx, refund)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Interface("refundID", refund.ID).Msg("refund failed")
+			if err = s.db.MarkRefundFailed(ctx, refund.ID, err, maxAttempts); err != nil {
+				zlog.Logger.Error().Err(err).Msg("failed to record refund failure")
+			}
+			continue
+		}
+
+		if err = s.db.MarkRefundSucceeded(ctx, refund.ID, result.ProviderRefundID); err != nil {
+			// the idempotency key keeps the provider from paying again on the next run
+			zlog.Logger.Error().Err(err).Interface("refundID", refund.ID).Msg("failed to mark refund succeeded")
+			continue
+		}
+
+		s.notifyRefund(ctx, refund)
+	}
+}
+
+func (s *Service) refund(ctx context.Context, refund *model.Refund) (*dto.RefundResult, error) {
+	if refund.Provider != s.payments.Name() {
+		return nil, fmt.Errorf("payment was made with provider %q, but %q is configured", refund.Provider, s.payments.Name())
+	}
+
+	return s.payments.Refund(ctx, dto.RefundRequest{
+		IdempotencyKey:    refund.ID.String(),
+		ProviderPaymentID: refund.ProviderPaymentID,
+		AmountMinor:       refund.AmountMinor,
+		Currency:          refund.Currency,
+		Reason:            refund.Reason,
+	})
+}
+
+func (s *Service) notifyRefund(ctx context.Context, refund *model.Refund) {
+	booking, err := s.db.GetBookingByID(ctx, refund.BookingID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Interface("refundID", refund.ID).Msg("failed to get refunded booking")
+		return
+	}
+	if booking.TelegramID == 0 {
+		return
+	}
+
+	tgMsg := fmt.Sprintf("Refund of %d.%02d %s (%d%%) for your booking %s to event (%v) is on its way",
+		refund.AmountMinor/100, refund.AmountMinor%100, refund.Currency, refund.Percent, booking.ID, booking.EventTitle)
+	if err = s.sender.SendToTelegram(booking.TelegramID, tgMsg); err != nil {
+		zlog.Logger.Error().Err(err).Interface("refundID", refund.ID).Msg("failed to notify about refund")
+	}
+}
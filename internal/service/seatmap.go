@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+)
+
+func (s *Service) CreateSeatMap(ctx context.Context, req *dto.CreateSeatMap) (*model.SeatMap, error) {
+	return s.db.CreateSeatMap(ctx, req)
+}
+
+func (s *Service) GetSeatMap(ctx context.Context, id uuid.UUID) (*model.SeatMap, error) {
+	return s.db.GetSeatMap(ctx, id)
+}
+
+func (s *Service) GetEventSeats(ctx context.Context, eventID uuid.UUID) ([]model.EventSeat, error) {
+	if _, err := s.db.GetEventByID(ctx, eventID); err != nil {
+		return nil, err
+	}
+	seats, err := s.db.GetEventSeats(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if seats == nil {
+		seats = []model.EventSeat{}
+	}
+	return seats, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/K1la/event-booker/internal/repository"
+	"github.com/google/uuid"
+	"time"
+)
+
+// CreateEventSeries expands the recurrence into occurrences, each on sale from now until
+// the sales cutoff before it, and stores them together with the series.
+// Occurrences at a venue keep the wall clock time of the venue across daylight saving changes.
+func (s *Service) CreateEventSeries(ctx context.Context, req *dto.CreateEventSeries) (*model.EventSeries, error) {
+	// the client sends starts_at with a fixed offset, which is only right until the venue's clocks change
+	loc, err := s.venueLocation(ctx, req.VenueID)
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		req.StartsAt = req.StartsAt.In(loc)
+	}
+
+	if err := repository.NormalizeRecurrence(req.StartsAt, &req.Recurrence); err != nil {
+		return nil, err
+	}
+	starts, err := repository.ExpandRecurrence(req.StartsAt, &req.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if req.TotalSeats, err = s.venueSeats(ctx, req.VenueID, req.TotalSeats); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	occurrences := make([]*dto.CreateEvent, 0, len(starts))
+	for _, eventAt := range starts {
+		occurrence := &dto.CreateEvent{
+			Title:      req.Title,
+			EventAt:    eventAt,
+			TotalSeats: req.TotalSeats,
+			VenueID:    req.VenueID,
+		}
+		s.defaultSalesWindow(occurrence, now)
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return s.db.CreateEventSeries(ctx, req, occurrences)
+}
+
+func (s *Service) GetEventSeries(ctx context.Context, id uuid.UUID) (*model.EventSeries, error) {
+	return s.db.GetEventSeries(ctx, id)
+}
+
+func (s *Service) UpdateEventSeries(ctx context.Context, id uuid.UUID, upd *dto.UpdateEventSeries) (*model.SeriesUpdate, error) {
+	return s.db.UpdateEventSeries(ctx, id, upd)
+}
@@ -1,15 +1,41 @@
 package service
 
+import (
+	"github.com/K1la/event-booker/internal/config"
+	"time"
+)
+
 type Service struct {
-	db     DBRepo
-	rbmq   RabbitMQ
-	sender Sender
+	db               DBRepo
+	rbmq             RabbitMQ
+	sender           Sender
+	payments         PaymentProvider
+	salesCutoff      time.Duration
+	refunds          refundPolicy
+	limits           config.Limits
+	holdTTL          time.Duration
+	idempotencyLease time.Duration
 }
 
-func New(d DBRepo, rq RabbitMQ, s Sender) *Service {
+func New(d DBRepo, rq RabbitMQ, s Sender, p PaymentProvider, cfg *config.Config) *Service {
+	holdTTL := defaultHoldTTL
+	if cfg.Holds.TTL > 0 {
+		holdTTL = time.Duration(cfg.Holds.TTL) * time.Second
+	}
+	idempotencyLease := defaultIdempotencyLease
+	if cfg.Idempotency.Lease > 0 {
+		idempotencyLease = time.Duration(cfg.Idempotency.Lease) * time.Second
+	}
+
 	return &Service{
-		db:     d,
-		rbmq:   rq,
-		sender: s,
+		db:               d,
+		rbmq:             rq,
+		sender:           s,
+		payments:         p,
+		salesCutoff:      time.Duration(max(cfg.Sales.CutoffMinutes, 0)) * time.Minute,
+		refunds:          newRefundPolicy(cfg.Refunds.Policy),
+		limits:           cfg.Limits,
+		holdTTL:          holdTTL,
+		idempotencyLease: idempotencyLease,
 	}
 }
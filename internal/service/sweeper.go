@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const (
+	defaultSweepInterval  = 30 * time.Second
+	defaultSweepBatchSize = 100
+)
+
+// StartExpirySweeper periodically expires pending bookings whose payment deadline has passed.
+// It does not depend on RabbitMQ: with the broker down it is the only expiry mechanism,
+// otherwise it is a safety net for messages that were lost or delayed.
+func (s *Service) StartExpirySweeper(ctx context.Context, interval time.Duration, batchSize int) {
+	if interval <= 0 {
+		interval = defaultSweepInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultSweepBatchSize
+	}
+
+	zlog.Logger.Info().Msgf("started expiry sweeper, interval %s", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				zlog.Logger.Info().Msg("expiry sweeper stopped")
+				return
+			case <-ticker.C:
+				s.sweepOverdueBookings(ctx, batchSize)
+			}
+		}
+	}()
+}
+
+func (s *Service) sweepOverdueBookings(ctx context.Context, batchSize int) {
+	overdue, err := s.db.GetOverdueBookings(ctx, batchSize)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to get overdue bookings")
+		return
+	}
+
+	for _, bookingID := range overdue {
+		if err = s.expireBooking(ctx, bookingID); err != nil {
+			zlog.Logger.Error().Err(err).Interface("bookingID", bookingID).Msg("failed to expire overdue booking")
+		}
+	}
+
+	if len(overdue) > 0 {
+		zlog.Logger.Info().Msgf("expiry sweeper processed %d overdue bookings", len(overdue))
+	}
+}
@@ -2,13 +2,170 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/K1la/event-booker/internal/repository"
 	"github.com/google/uuid"
+	"time"
 )
 
-func (s *Service) ConfirmBookingPayment(ctx context.Context, eventID uuid.UUID) error {
-	return s.db.ConfirmBookingPayment(ctx, eventID)
+// defaultOptOutWindow is how long attendees may leave a rescheduled event when the organizer gives no deadline.
+const defaultOptOutWindow = 48 * time.Hour
+
+const notificationTimeLayout = "02.01.2006 15:04"
+
+func (s *Service) UpdateEvent(ctx context.Context, eventID uuid.UUID, upd *dto.UpdateEvent) (*model.Event, error) {
+	updated, err := s.db.UpdateEvent(ctx, eventID, upd)
+	if err != nil {
+		return nil, err
+	}
+
+	// a capacity increase may let waiting users in
+	if upd.TotalSeats != nil {
+		s.promoteWaitlist(ctx, eventID)
+	}
+
+	return updated, nil
+}
+
+func (s *Service) ConfirmBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error) {
+	booking, err := s.db.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !repository.CanTransition(booking.Status, repository.StatusConfirmed) {
+		return nil, fmt.Errorf("%w: %s -> %s",
+			repository.ErrInvalidStatusTransition, booking.Status, repository.StatusConfirmed)
+	}
+
+	// paid bookings are confirmed only by the payment provider's webhook
+	if booking.PriceMinor > 0 {
+		return nil, repository.ErrPaymentRequired
+	}
+
+	return s.db.ConfirmBooking(ctx, bookingID, dto.StatusChange{
+		Actor:  repository.ActorUser,
+		Reason: repository.ReasonPaymentConfirmed,
+	})
+}
+
+// CancelBooking cancels the booking on behalf of actor, the attendee or an admin.
+func (s *Service) CancelBooking(ctx context.Context, bookingID uuid.UUID, actor string, req *dto.CancelBooking) (*model.Booking, error) {
+	change := dto.StatusChange{Actor: actor, Reason: req.Reason}
+	switch change.Actor {
+	case repository.ActorUser:
+		if change.Reason == "" {
+			change.Reason = repository.ReasonCancelledByUser
+		}
+	case repository.ActorAdmin:
+		if change.Reason == "" {
+			change.Reason = repository.ReasonCancelledByAdmin
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", repository.ErrInvalidActor, change.Actor)
+	}
+
+	booking, err := s.db.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !repository.CanTransition(booking.Status, repository.StatusCancelled) {
+		return nil, fmt.Errorf("%w: %s -> %s",
+			repository.ErrInvalidStatusTransition, booking.Status, repository.StatusCancelled)
+	}
+
+	// the refund policy only matters for money already taken. The payment may still confirm the booking
+	// before the repository locks it, so the percent is passed for every priced booking and the
+	// repository refunds only if the booking turns out confirmed under the lock.
+	var refundPercent int
+	if booking.PriceMinor > 0 {
+		event, err := s.db.GetEventByID(ctx, booking.EventID)
+		if err != nil {
+			return nil, err
+		}
+		refundPercent = s.refunds.percent(event.EventAt, time.Now())
+	}
+
+	cancelled, err := s.db.CancelBooking(ctx, bookingID, change, refundPercent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.promoteWaitlist(ctx, cancelled.EventID)
+
+	return cancelled, nil
+}
+
+// CancelEvent calls the event off and queues a message for everyone who held a booking or waited for one;
+// those who only waited are told they left the waitlist rather than lost a booking.
+// The messages are delivered by the notifier, so a slow or failing Telegram does not block the cancellation.
+func (s *Service) CancelEvent(ctx context.Context, eventID uuid.UUID, req *dto.CancelEvent) (*model.EventCancellation, error) {
+	event, err := s.db.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	bookingText := fmt.Sprintf("Event (%v) on %s was cancelled by the organizer, your booking is cancelled",
+		event.Title, localTime(event, event.EventAt))
+	waitlistText := fmt.Sprintf("Event (%v) on %s was cancelled by the organizer, you are no longer on its waitlist",
+		event.Title, localTime(event, event.EventAt))
+	if req.Reason != "" {
+		bookingText += ". Reason: " + req.Reason
+		waitlistText += ". Reason: " + req.Reason
+	}
+
+	cancellation, err := s.db.CancelEvent(ctx, eventID, bookingText, waitlistText)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cancelled bookings gave their seats back to the pools the event shares with other events
+	s.promoteWaitlist(ctx, eventID)
+
+	return cancellation, nil
+}
+
+// RescheduleEvent moves the event and tells every attendee the new time and until when they may leave.
+// Bookings of attendees who do not opt out stay valid for the new time.
+func (s *Service) RescheduleEvent(ctx context.Context, eventID uuid.UUID, req *dto.RescheduleEvent) (*model.RescheduleResult, error) {
+	event, err := s.db.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the default deadline is derived from the new time, so a bad time must not surface as a bad deadline
+	if !req.EventAt.After(time.Now()) {
+		return nil, repository.ErrInvalidRescheduleTime
+	}
+
+	if req.OptOutDeadline == nil {
+		deadline := time.Now().Add(defaultOptOutWindow)
+		if deadline.After(req.EventAt) {
+			deadline = req.EventAt
+		}
+		req.OptOutDeadline = &deadline
+	}
+
+	text := fmt.Sprintf("Event (%v) was moved from %s to %s. Your booking stays valid; "+
+		"if the new time does not suit you, you can cancel it and release your seats until %s",
+		event.Title,
+		localTime(event, event.EventAt),
+		localTime(event, req.EventAt),
+		localTime(event, *req.OptOutDeadline))
+
+	return s.db.RescheduleEvent(ctx, eventID, req, text)
 }
-func (s *Service) CancelBooking(ctx context.Context, booking *dto.QueueMessage) error {
-	return s.db.CancelBooking(ctx, booking)
+
+func (s *Service) OptOutBooking(ctx context.Context, bookingID uuid.UUID) (*model.Booking, error) {
+	booking, err := s.db.OptOutBooking(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.promoteWaitlist(ctx, booking.EventID)
+
+	return booking, nil
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+func (s *Service) CreateVenue(ctx context.Context, req *dto.CreateVenue) (*model.Venue, error) {
+	return s.db.CreateVenue(ctx, req)
+}
+
+func (s *Service) GetVenues(ctx context.Context) ([]*model.Venue, error) {
+	return s.db.GetVenues(ctx)
+}
+
+func (s *Service) GetVenueByID(ctx context.Context, id uuid.UUID) (*model.Venue, error) {
+	return s.db.GetVenueByID(ctx, id)
+}
+
+func (s *Service) UpdateVenue(ctx context.Context, id uuid.UUID, upd *dto.UpdateVenue) (*model.Venue, error) {
+	return s.db.UpdateVenue(ctx, id, upd)
+}
+
+func (s *Service) DeleteVenue(ctx context.Context, id uuid.UUID) error {
+	return s.db.DeleteVenue(ctx, id)
+}
+
+// venueSeats returns seats, or the capacity of the venue if seats are not given.
+func (s *Service) venueSeats(ctx context.Context, venueID *uuid.UUID, seats int) (int, error) {
+	if venueID == nil {
+		return seats, nil
+	}
+	venue, err := s.db.GetVenueByID(ctx, *venueID)
+	if err != nil {
+		return 0, err
+	}
+	if seats == 0 {
+		return venue.Capacity, nil
+	}
+	return seats, nil
+}
+
+// venueLocation returns the timezone of the venue, or nil if there is no venue.
+func (s *Service) venueLocation(ctx context.Context, venueID *uuid.UUID) (*time.Location, error) {
+	if venueID == nil {
+		return nil, nil
+	}
+	venue, err := s.db.GetVenueByID(ctx, *venueID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(venue.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone of venue: %w", err)
+	}
+	return loc, nil
+}
+
+// localTime formats t for a Telegram message in the timezone of the event's venue.
+func localTime(event *model.Event, t time.Time) string {
+	if loc := event.Location(); loc != nil {
+		return t.In(loc).Format(notificationTimeLayout + " MST")
+	}
+	return t.Format(notificationTimeLayout)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/K1la/event-booker/internal/dto"
+	"github.com/K1la/event-booker/internal/model"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+)
+
+func (s *Service) JoinWaitlist(ctx context.Context, entry *dto.JoinWaitlist) (*model.WaitlistEntry, error) {
+	if entry.PlacesCount == 0 {
+		entry.PlacesCount = len(entry.SeatIDs)
+	}
+
+	joined, err := s.db.JoinWaitlist(ctx, entry, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	// seats may have come back between the failed booking and joining the waitlist
+	s.promoteWaitlist(ctx, entry.EventID)
+
+	return s.db.GetWaitlistEntryByID(ctx, joined.ID)
+}
+
+// promoteWaitlist hands seats that came back to the event to its waitlist. Seats that went back
+// to a shared capacity pool may also let in users of the other events of the pool, which were sold
+// out only because of it, so their waitlists are promoted as well.
+// Failures are only logged: the caller has already released the seats successfully.
+func (s *Service) promoteWaitlist(ctx context.Context, eventID uuid.UUID) {
+	eventIDs, err := s.db.GetPooledEventIDs(ctx, eventID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Interface("eventID", eventID).Msg("failed to get pooled events")
+		eventIDs = []uuid.UUID{eventID}
+	}
+
+	for _, id := range eventIDs {
+		s.promoteEventWaitlist(ctx, id)
+	}
+}
+
+// promoteEventWaitlist hands the free seats of one event to the oldest waitlist entries that fit.
+// Every promoted booking gets its own payment deadline (queued in the outbox by the repository)
+// and the user is notified.
+func (s *Service) promoteEventWaitlist(ctx context.Context, eventID uuid.UUID) {
+	promoted, err := s.db.PromoteWaitlist(ctx, eventID, s.limits)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Interface("eventID", eventID).Msg("failed to promote waitlist")
+		return
+	}
+	if len(promoted) == 0 {
+		return
+	}
+
+	event, err := s.db.GetEventByID(ctx, eventID)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Interface("eventID", eventID).Msg("failed to get event for waitlist notification")
+		return
+	}
+
+	for _, booking := range promoted {
+		if booking.TelegramID == 0 {
+			continue
+		}
+
+		tgMsg := fmt.Sprintf("Seats for event (%v) became available: your booking %s is waiting for payment",
+			event.Title, booking.ID)
+		if err = s.sender.SendToTelegram(booking.TelegramID, tgMsg); err != nil {
+			zlog.Logger.Error().Err(err).Interface("bookingID", booking.ID).Msg("failed to notify promoted user")
+		}
+	}
+
+	zlog.Logger.Info().Interface("eventID", eventID).Msgf("promoted %d waitlist entries", len(promoted))
+}
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Bookings created before places_count was stored have no seat count.
+-- If an event has exactly one such active booking, it gets every seat the event
+-- is holding that the known bookings do not account for.
+WITH held AS (
+    SELECT e.id AS event_id,
+           e.total_seats - e.available_seats
+               - COALESCE(SUM(b.places_count) FILTER (WHERE b.places_count IS NOT NULL), 0) AS unaccounted,
+           COUNT(*) FILTER (WHERE b.places_count IS NULL) AS unknown
+    FROM events e
+    JOIN bookings b ON b.event_id = e.id AND b.status IN ('pending', 'confirmed')
+    GROUP BY e.id
+)
+UPDATE bookings b
+SET places_count = GREATEST(h.unaccounted, 1)
+FROM held h
+WHERE b.event_id = h.event_id
+  AND h.unknown = 1
+  AND b.places_count IS NULL
+  AND b.status IN ('pending', 'confirmed');
+
+-- Every other legacy booking is counted as a single seat.
+UPDATE bookings SET places_count = 1 WHERE places_count IS NULL;
+
+-- Bring the event counters in line with what the bookings now hold.
+UPDATE events e
+SET available_seats = GREATEST(e.total_seats - COALESCE((
+        SELECT SUM(b.places_count)
+        FROM bookings b
+        WHERE b.event_id = e.id AND b.status IN ('pending', 'confirmed')
+    ), 0), 0),
+    updated_at = NOW();
+
+ALTER TABLE bookings ALTER COLUMN places_count SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings ALTER COLUMN places_count DROP NOT NULL;
+-- +goose StatementEnd
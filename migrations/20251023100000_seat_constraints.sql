@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Oversold events could have been left with a negative counter.
+UPDATE events
+SET available_seats = GREATEST(LEAST(available_seats, total_seats), 0)
+WHERE available_seats < 0 OR available_seats > total_seats;
+
+ALTER TABLE events ADD CONSTRAINT events_total_seats_check
+    CHECK ( total_seats >= 0 );
+ALTER TABLE events ADD CONSTRAINT events_available_seats_check
+    CHECK ( available_seats >= 0 AND available_seats <= total_seats );
+
+-- Legacy zero-seat bookings are left as they are; every new row must take at least one seat.
+ALTER TABLE bookings ADD CONSTRAINT bookings_places_count_check
+    CHECK ( places_count > 0 ) NOT VALID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_places_count_check;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_available_seats_check;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_total_seats_check;
+-- +goose StatementEnd
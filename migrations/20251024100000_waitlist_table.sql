@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS waitlist(
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id     UUID NOT NULL REFERENCES events(id),
+    telegram_id  INT NOT NULL,
+    places_count INT NOT NULL CHECK ( places_count > 0 ),
+    status       TEXT NOT NULL CHECK ( status IN ('waiting', 'promoted')) DEFAULT 'waiting',
+    booking_id   UUID REFERENCES bookings(id),
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_waitlist_event_waiting ON waitlist(event_id, created_at) WHERE status = 'waiting';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS waitlist;
+-- +goose StatementEnd
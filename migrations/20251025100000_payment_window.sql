@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN IF NOT EXISTS payment_window_minutes INT NOT NULL DEFAULT 15
+    CHECK ( payment_window_minutes > 0 );
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+UPDATE bookings SET expires_at = created_at + INTERVAL '15 minutes' WHERE expires_at IS NULL;
+ALTER TABLE bookings ALTER COLUMN expires_at SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE events DROP COLUMN IF EXISTS payment_window_minutes;
+-- +goose StatementEnd
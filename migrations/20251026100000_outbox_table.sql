@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS outbox(
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id   UUID NOT NULL REFERENCES bookings(id),
+    deliver_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts     INT NOT NULL DEFAULT 0,
+    last_error   TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_unpublished ON outbox(created_at) WHERE published_at IS NULL;
+
+-- pending bookings created before the outbox existed may never have had their expiry published
+INSERT INTO outbox(booking_id, deliver_at)
+SELECT id, expires_at FROM bookings WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
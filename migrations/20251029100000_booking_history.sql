@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS booking_history(
+    id          BIGSERIAL PRIMARY KEY,
+    booking_id  UUID NOT NULL REFERENCES bookings(id),
+    from_status TEXT,
+    to_status   TEXT NOT NULL,
+    actor       TEXT NOT NULL,
+    reason      TEXT NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_booking_history_booking_id ON booking_history(booking_id, created_at);
+
+-- history is append-only: entries can be neither rewritten nor removed
+CREATE OR REPLACE FUNCTION booking_history_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'booking_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER booking_history_no_update
+    BEFORE UPDATE OR DELETE ON booking_history
+    FOR EACH ROW EXECUTE FUNCTION booking_history_append_only();
+
+-- bookings created before the history existed get what can be reconstructed from their row
+INSERT INTO booking_history(booking_id, from_status, to_status, actor, reason, created_at)
+SELECT id, NULL, 'pending', 'system', 'booking created (reconstructed)', created_at FROM bookings;
+
+INSERT INTO booking_history(booking_id, from_status, to_status, actor, reason, created_at)
+SELECT id, 'pending', status, 'system', 'status change (reconstructed)', updated_at
+FROM bookings WHERE status <> 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS booking_history;
+DROP FUNCTION IF EXISTS booking_history_append_only();
+-- +goose StatementEnd
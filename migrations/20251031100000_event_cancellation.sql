@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active'
+    CHECK ( status IN ('active', 'cancelled'));
+ALTER TABLE events ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE waitlist DROP CONSTRAINT IF EXISTS waitlist_status_check;
+ALTER TABLE waitlist ADD CONSTRAINT waitlist_status_check
+    CHECK ( status IN ('waiting', 'promoted', 'cancelled'));
+
+CREATE TABLE IF NOT EXISTS notifications(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id    UUID NOT NULL REFERENCES events(id),
+    telegram_id INT NOT NULL,
+    text        TEXT NOT NULL,
+    status      TEXT NOT NULL CHECK ( status IN ('queued', 'sent', 'failed')) DEFAULT 'queued',
+    attempts    INT NOT NULL DEFAULT 0,
+    last_error  TEXT,
+    sent_at     TIMESTAMP WITH TIME ZONE,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_notifications_queued ON notifications(created_at) WHERE status = 'queued';
+CREATE INDEX idx_notifications_event_id ON notifications(event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notifications;
+
+DELETE FROM waitlist WHERE status = 'cancelled';
+ALTER TABLE waitlist DROP CONSTRAINT IF EXISTS waitlist_status_check;
+ALTER TABLE waitlist ADD CONSTRAINT waitlist_status_check
+    CHECK ( status IN ('waiting', 'promoted'));
+
+ALTER TABLE events DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE events DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
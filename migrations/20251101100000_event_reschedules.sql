@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_reschedules(
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id         UUID NOT NULL REFERENCES events(id),
+    old_event_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+    new_event_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+    opt_out_deadline TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_event_reschedules_event_id ON event_reschedules(event_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_reschedules;
+-- +goose StatementEnd
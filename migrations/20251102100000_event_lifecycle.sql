@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_status_check;
+
+-- events created before the lifecycle were bookable right away
+UPDATE events SET status = 'published' WHERE status = 'active';
+
+ALTER TABLE events ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE events ADD CONSTRAINT events_status_check
+    CHECK ( status IN ('draft', 'published', 'sales_closed', 'completed', 'cancelled'));
+
+CREATE INDEX idx_events_open_event_at ON events(event_at) WHERE status IN ('published', 'sales_closed');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_events_open_event_at;
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_status_check;
+UPDATE events SET status = 'active' WHERE status <> 'cancelled';
+ALTER TABLE events ALTER COLUMN status SET DEFAULT 'active';
+ALTER TABLE events ADD CONSTRAINT events_status_check
+    CHECK ( status IN ('active', 'cancelled'));
+-- +goose StatementEnd
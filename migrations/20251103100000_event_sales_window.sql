@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN IF NOT EXISTS sale_opens_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS sale_closes_at TIMESTAMP WITH TIME ZONE;
+
+-- existing events were on sale since they were created and until they started
+UPDATE events
+SET sale_opens_at = COALESCE(created_at, NOW()),
+    sale_closes_at = event_at
+WHERE sale_opens_at IS NULL OR sale_closes_at IS NULL;
+
+UPDATE events SET sale_opens_at = sale_closes_at WHERE sale_opens_at > sale_closes_at;
+
+ALTER TABLE events ALTER COLUMN sale_opens_at SET NOT NULL;
+ALTER TABLE events ALTER COLUMN sale_closes_at SET NOT NULL;
+ALTER TABLE events ADD CONSTRAINT events_sales_window_check CHECK ( sale_opens_at <= sale_closes_at );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_sales_window_check;
+ALTER TABLE events DROP COLUMN IF EXISTS sale_closes_at;
+ALTER TABLE events DROP COLUMN IF EXISTS sale_opens_at;
+-- +goose StatementEnd
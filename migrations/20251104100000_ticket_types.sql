@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ticket_types(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id    UUID NOT NULL REFERENCES events(id),
+    name        TEXT NOT NULL,
+    price_minor BIGINT NOT NULL CHECK ( price_minor >= 0 ),
+    currency    CHAR(3) NOT NULL,
+    capacity    INT NOT NULL CHECK ( capacity > 0 ),
+    available   INT NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (event_id, name),
+    CHECK ( available >= 0 AND available <= capacity )
+);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS ticket_type_id UUID REFERENCES ticket_types(id);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS price_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS currency CHAR(3);
+
+ALTER TABLE waitlist ADD COLUMN IF NOT EXISTS ticket_type_id UUID REFERENCES ticket_types(id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE waitlist DROP COLUMN IF EXISTS ticket_type_id;
+
+ALTER TABLE bookings DROP COLUMN IF EXISTS currency;
+ALTER TABLE bookings DROP COLUMN IF EXISTS price_minor;
+ALTER TABLE bookings DROP COLUMN IF EXISTS ticket_type_id;
+
+DROP TABLE IF EXISTS ticket_types;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS payments(
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id          UUID NOT NULL REFERENCES bookings(id),
+    provider            TEXT NOT NULL,
+    provider_payment_id TEXT NOT NULL,
+    amount_minor        BIGINT NOT NULL CHECK ( amount_minor > 0 ),
+    currency            CHAR(3) NOT NULL,
+    status              TEXT NOT NULL CHECK ( status IN ('pending', 'succeeded', 'failed')) DEFAULT 'pending',
+    checkout_url        TEXT NOT NULL,
+    created_at          TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at          TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (provider, provider_payment_id)
+);
+
+CREATE INDEX idx_payments_booking_id ON payments(booking_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payments;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS refunds(
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id         UUID NOT NULL REFERENCES bookings(id),
+    payment_id         UUID NOT NULL REFERENCES payments(id),
+    provider_refund_id TEXT,
+    amount_minor       BIGINT NOT NULL CHECK ( amount_minor > 0 ),
+    currency           CHAR(3) NOT NULL,
+    percent            INT NOT NULL CHECK ( percent BETWEEN 1 AND 100 ),
+    reason             TEXT NOT NULL,
+    status             TEXT NOT NULL CHECK ( status IN ('pending', 'succeeded', 'failed')) DEFAULT 'pending',
+    attempts           INT NOT NULL DEFAULT 0,
+    last_error         TEXT,
+    refunded_at        TIMESTAMP WITH TIME ZONE,
+    created_at         TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at         TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (payment_id)
+);
+
+CREATE INDEX idx_refunds_pending ON refunds(created_at) WHERE status = 'pending';
+CREATE INDEX idx_refunds_booking_id ON refunds(booking_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refunds;
+-- +goose StatementEnd
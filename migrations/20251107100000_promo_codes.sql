@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS promo_codes(
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code            TEXT NOT NULL UNIQUE,
+    discount_type   TEXT NOT NULL CHECK ( discount_type IN ('percent', 'fixed')),
+    discount_value  BIGINT NOT NULL CHECK ( discount_value > 0 ),
+    currency        CHAR(3),
+    max_uses        INT CHECK ( max_uses > 0 ),
+    used_count      INT NOT NULL DEFAULT 0 CHECK ( used_count >= 0 AND (max_uses IS NULL OR used_count <= max_uses)),
+    expires_at      TIMESTAMP WITH TIME ZONE,
+    -- empty means the code is valid for every event or ticket type
+    event_ids       UUID[] NOT NULL DEFAULT '{}',
+    ticket_type_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at      TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ( discount_type = 'percent' AND discount_value <= 100 OR discount_type = 'fixed' AND currency IS NOT NULL )
+);
+
+CREATE TABLE IF NOT EXISTS promo_redemptions(
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promo_code_id  UUID NOT NULL REFERENCES promo_codes(id),
+    booking_id     UUID NOT NULL UNIQUE REFERENCES bookings(id),
+    discount_minor BIGINT NOT NULL CHECK ( discount_minor > 0 ),
+    currency       CHAR(3) NOT NULL,
+    status         TEXT NOT NULL CHECK ( status IN ('applied', 'released')) DEFAULT 'applied',
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_promo_redemptions_promo_code_id ON promo_redemptions(promo_code_id);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS promo_code_id UUID REFERENCES promo_codes(id);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS discount_minor BIGINT NOT NULL DEFAULT 0 CHECK ( discount_minor >= 0 );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE bookings DROP COLUMN IF EXISTS discount_minor;
+ALTER TABLE bookings DROP COLUMN IF EXISTS promo_code_id;
+
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS seat_holds(
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id       UUID NOT NULL REFERENCES events(id),
+    ticket_type_id UUID REFERENCES ticket_types(id),
+    telegram_id    INT,
+    places_count   INT NOT NULL CHECK ( places_count > 0 ),
+    status         TEXT NOT NULL CHECK ( status IN ('active', 'converted', 'expired', 'released')) DEFAULT 'active',
+    booking_id     UUID REFERENCES bookings(id),
+    expires_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_seat_holds_active ON seat_holds(expires_at) WHERE status = 'active';
+CREATE INDEX idx_seat_holds_event_id ON seat_holds(event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS seat_holds;
+-- +goose StatementEnd
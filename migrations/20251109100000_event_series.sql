@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_series(
+    id                     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title                  TEXT NOT NULL,
+    total_seats            INT NOT NULL CHECK ( total_seats > 0 ),
+    payment_window_minutes INT NOT NULL CHECK ( payment_window_minutes > 0 ),
+    frequency              TEXT NOT NULL CHECK ( frequency IN ('daily', 'weekly', 'monthly')),
+    interval               INT NOT NULL DEFAULT 1 CHECK ( interval > 0 ),
+    weekdays               INT[] NOT NULL DEFAULT '{}',
+    starts_at              TIMESTAMP WITH TIME ZONE NOT NULL,
+    until                  TIMESTAMP WITH TIME ZONE,
+    count                  INT CHECK ( count > 0 ),
+    created_at             TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at             TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ( (until IS NULL) <> (count IS NULL) )
+);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES event_series(id);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_position INT;
+
+CREATE UNIQUE INDEX idx_events_series_position ON events(series_id, series_position) WHERE series_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN IF EXISTS series_position;
+ALTER TABLE events DROP COLUMN IF EXISTS series_id;
+
+DROP TABLE IF EXISTS event_series;
+-- +goose StatementEnd
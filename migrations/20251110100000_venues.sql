@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS venues(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    address    TEXT NOT NULL DEFAULT '',
+    timezone   TEXT NOT NULL,
+    capacity   INT NOT NULL CHECK ( capacity > 0 ),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS venue_id UUID REFERENCES venues(id);
+
+CREATE INDEX idx_events_venue_id ON events(venue_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN IF EXISTS venue_id;
+
+DROP TABLE IF EXISTS venues;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS capacity_pools(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    capacity   INT NOT NULL CHECK ( capacity > 0 ),
+    available  INT NOT NULL CHECK ( available >= 0 AND available <= capacity ),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS pool_id UUID REFERENCES capacity_pools(id);
+ALTER TABLE ticket_types ADD COLUMN IF NOT EXISTS pool_id UUID REFERENCES capacity_pools(id);
+
+CREATE INDEX idx_events_pool_id ON events(pool_id);
+CREATE INDEX idx_ticket_types_pool_id ON ticket_types(pool_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ticket_types DROP COLUMN IF EXISTS pool_id;
+ALTER TABLE events DROP COLUMN IF EXISTS pool_id;
+
+DROP TABLE IF EXISTS capacity_pools;
+-- +goose StatementEnd
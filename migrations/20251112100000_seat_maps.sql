@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS seat_maps(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    venue_id   UUID REFERENCES venues(id),
+    event_id   UUID REFERENCES events(id),
+    name       TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ( (venue_id IS NULL) <> (event_id IS NULL) )
+);
+
+-- a venue or an event has one seat map
+CREATE UNIQUE INDEX idx_seat_maps_venue_id ON seat_maps(venue_id) WHERE venue_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_seat_maps_event_id ON seat_maps(event_id) WHERE event_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS seats(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seat_map_id UUID NOT NULL REFERENCES seat_maps(id),
+    section     TEXT NOT NULL,
+    seat_row    TEXT NOT NULL,
+    number      INT NOT NULL CHECK ( number > 0 ),
+    wheelchair  BOOLEAN NOT NULL DEFAULT false,
+    companion   BOOLEAN NOT NULL DEFAULT false,
+    position    INT NOT NULL,
+    UNIQUE (seat_map_id, section, seat_row, number)
+);
+
+CREATE TABLE IF NOT EXISTS seat_allocations(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id   UUID NOT NULL REFERENCES events(id),
+    seat_id    UUID NOT NULL REFERENCES seats(id),
+    booking_id UUID NOT NULL REFERENCES bookings(id),
+    status     TEXT NOT NULL DEFAULT 'active' CHECK ( status IN ('active', 'released') ),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- the last line of defence against selling a seat twice: only one active allocation per seat and event
+CREATE UNIQUE INDEX idx_seat_allocations_active ON seat_allocations(event_id, seat_id) WHERE status = 'active';
+CREATE INDEX idx_seat_allocations_booking_id ON seat_allocations(booking_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS seat_allocations;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS seat_maps;
+-- +goose StatementEnd
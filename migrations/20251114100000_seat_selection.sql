@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a seat hold owns its seats until it becomes a booking, which then takes them over
+ALTER TABLE seat_allocations ALTER COLUMN booking_id DROP NOT NULL;
+ALTER TABLE seat_allocations ADD COLUMN hold_id UUID REFERENCES seat_holds(id);
+ALTER TABLE seat_allocations ADD CONSTRAINT seat_allocations_owner_check CHECK ( booking_id IS NOT NULL OR hold_id IS NOT NULL );
+CREATE INDEX idx_seat_allocations_hold_id ON seat_allocations(hold_id);
+
+-- an entry for an event with a seat map waits for the seats the user chose
+ALTER TABLE waitlist ADD COLUMN seat_ids UUID[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE waitlist DROP COLUMN IF EXISTS seat_ids;
+
+DELETE FROM seat_allocations WHERE booking_id IS NULL;
+DROP INDEX IF EXISTS idx_seat_allocations_hold_id;
+ALTER TABLE seat_allocations DROP CONSTRAINT IF EXISTS seat_allocations_owner_check;
+ALTER TABLE seat_allocations DROP COLUMN IF EXISTS hold_id;
+ALTER TABLE seat_allocations ALTER COLUMN booking_id SET NOT NULL;
+-- +goose StatementEnd
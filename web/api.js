@@ -59,15 +59,22 @@ class EventBookerAPI {
     }
 
     // Подтвердить бронирование
-    async confirmBooking(eventId) {
-        return this.request(`${this.baseURL}/${eventId}/confirm`, {
+    async confirmBooking(bookingId) {
+        return this.request(`/api/bookings/${bookingId}/confirm`, {
             method: 'POST'
         });
     }
 
-    // Отменить бронирование
-    async cancelBooking(eventId) {
-        return this.request(`${this.baseURL}/${eventId}`, {
+    // Отменить бронирование от имени участника
+    async cancelBooking(bookingId) {
+        return this.request(`/api/bookings/${bookingId}/cancel`, {
+            method: 'POST'
+        });
+    }
+
+    // Отменить бронирование от имени администратора
+    async adminCancelBooking(bookingId) {
+        return this.request(`/api/admin/bookings/${bookingId}/cancel`, {
             method: 'POST'
         });
     }
@@ -138,7 +145,8 @@ class DOMUtils {
         const statusMap = {
             'pending': 'Ожидает подтверждения',
             'confirmed': 'Подтверждено',
-            'cancelled': 'Отменено'
+            'cancelled': 'Отменено',
+            'expired': 'Истекло'
         };
         return statusMap[status] || status;
     }
@@ -197,6 +205,11 @@ class EventManager {
                             <div class="booking-status ${DOMUtils.getStatusClass(booking.status)}">
                                 ${DOMUtils.formatBookingStatus(booking.status)}
                             </div>
+                            ${['pending', 'confirmed'].includes(booking.status) ? `
+                                <button class="btn btn-danger btn-small" onclick="EventManager.cancelBooking('${booking.id}')">
+                                    Отменить
+                                </button>
+                            ` : ''}
                         </div>
                     `).join('')}
                 </div>
@@ -241,11 +254,7 @@ class EventManager {
                         <button class="btn btn-secondary btn-small" onclick="EventManager.showConfirmModal('${event.id}')">
                             Подтвердить бронь
                         </button>
-                    ` : `
-                        <button class="btn btn-danger btn-small" onclick="EventManager.cancelBooking('${event.id}')">
-                            Отменить бронь
-                        </button>
-                    `}
+                    ` : ''}
                 </div>
             </div>
         `;
@@ -280,12 +289,15 @@ class EventManager {
         }
 
         try {
-            await api.bookEvent(eventId, {
+            const booking = await api.bookEvent(eventId, {
                 telegram_id: telegramId,
                 places_count: placesCount
             });
             
-            DOMUtils.showNotification('Место успешно забронировано!', 'success');
+            DOMUtils.showNotification(
+                `Место успешно забронировано! ID бронирования: ${booking.id}. Оплатите до ${DOMUtils.formatDate(booking.expires_at)}`,
+                'success'
+            );
             DOMUtils.hideModal('bookModal');
             
             // Обновить список мероприятий
@@ -301,10 +313,15 @@ class EventManager {
 
     // Подтвердить бронирование
     static async confirmBooking() {
-        const eventId = document.getElementById('confirmEventId').value;
+        const bookingId = document.getElementById('confirmBookingIdInput').value.trim();
+
+        if (!bookingId) {
+            DOMUtils.showNotification('Введите ID бронирования', 'error');
+            return;
+        }
 
         try {
-            await api.confirmBooking(eventId);
+            await api.confirmBooking(bookingId);
             
             DOMUtils.showNotification('Бронирование успешно подтверждено!', 'success');
             DOMUtils.hideModal('confirmModal');
@@ -321,13 +338,13 @@ class EventManager {
     }
 
     // Отменить бронирование
-    static async cancelBooking(eventId) {
+    static async cancelBooking(bookingId) {
         if (!confirm('Вы уверены, что хотите отменить это бронирование?')) {
             return;
         }
 
         try {
-            await api.cancelBooking(eventId);
+            await api.adminCancelBooking(bookingId);
             
             DOMUtils.showNotification('Бронирование успешно отменено!', 'success');
             
@@ -374,7 +391,8 @@ class FormManager {
             await api.createEvent({
                 title: title,
                 event_at: eventAt,
-                total_seats: totalSeats
+                total_seats: totalSeats,
+                status: 'published'
             });
             
             DOMUtils.showNotification('Мероприятие успешно создано!', 'success');